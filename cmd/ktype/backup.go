@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"ktype/internal/storage"
+)
+
+// runBackup archives the whole profile into one file
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := fs.String("output", "", "archive to write, or - for stdout (default a new file in the backups directory)")
+	dataDir := fs.String("data-dir", "", "keep all ktype files in this directory (default $KTYPE_HOME or the XDG directories)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	root, err := storage.ResolveRoot(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		_, err := root.Backup(os.Stdout)
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = root.BackupPath(time.Now())
+	}
+	manifest, err := root.SaveBackup(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("backed up %d files to %s\n", len(manifest.Files), path)
+	return nil
+}
+
+// runRestore validates a backup archive and applies it to the profile
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: ktype restore [flags] ARCHIVE (or - for stdin)")
+		fs.PrintDefaults()
+	}
+	mode := fs.String("mode", "merge", "merge: add the backup's history to this profile; replace: make this profile a copy of the backup")
+	dataDir := fs.String("data-dir", "", "keep all ktype files in this directory (default $KTYPE_HOME or the XDG directories)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one archive to restore")
+	}
+
+	restoreMode, err := storage.ParseRestoreMode(*mode)
+	if err != nil {
+		return err
+	}
+	root, err := storage.ResolveRoot(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	var backup *storage.Backup
+	if fs.Arg(0) == "-" {
+		backup, err = storage.ReadBackup(os.Stdin)
+	} else {
+		backup, err = storage.OpenBackup(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := root.Restore(backup, restoreMode)
+	if err != nil {
+		return err
+	}
+
+	created := backup.Manifest.Created.Local().Format("2006-01-02 15:04")
+	if restoreMode == storage.RestoreReplace {
+		fmt.Printf("replaced %d files with the backup from %s (previous files kept as .bak)\n", result.Files, created)
+	} else {
+		fmt.Printf("merged the backup from %s: %d scores, %d heatmap keys, %d n-grams, %d challenges and %d word lists added or updated\n",
+			created, result.Scores, result.Keys, result.NGrams, result.Challenges, result.WordLists)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ktype/internal/storage"
+)
+
+// dataKinds lists what can be exported and imported
+const dataKinds = "scores, heatmap or challenges"
+
+// runExport writes scores, heatmap or challenges to stdout or a file
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	what := fs.String("what", "scores", "data to export: "+dataKinds)
+	format := fs.String("format", "", "csv or json (default from the output file name, else csv)")
+	since := fs.String("since", "", "only export data from this date on (YYYY-MM-DD)")
+	output := fs.String("output", "-", "file to write, or - for stdout")
+	dataDir := fs.String("data-dir", "", "keep all ktype files in this directory (default $KTYPE_HOME or the XDG directories)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	exportFormat, err := formatFor(*format, *output)
+	if err != nil {
+		return err
+	}
+	var from time.Time
+	if *since != "" {
+		if from, err = time.ParseInLocation("2006-01-02", *since, time.Local); err != nil {
+			return fmt.Errorf("invalid --since date %q (want YYYY-MM-DD)", *since)
+		}
+	}
+	root, err := storage.ResolveRoot(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	// Pick the data before touching the output, so a mistyped --what doesn't
+	// truncate an existing file
+	var write func(w io.Writer) error
+	switch *what {
+	case "scores":
+		write = func(w io.Writer) error {
+			var scores []storage.Score
+			for _, s := range storage.NewLeaderboard(root).Scores {
+				if !s.Date.Before(from) {
+					scores = append(scores, s)
+				}
+			}
+			return storage.ExportScores(w, exportFormat, scores)
+		}
+	case "heatmap":
+		write = func(w io.Writer) error {
+			hm := storage.NewHeatmap(root)
+			defer hm.Close()
+			var stats []storage.KeyStats
+			for _, s := range hm.Stats() {
+				if !s.LastUsed.Before(from) {
+					stats = append(stats, s)
+				}
+			}
+			return storage.ExportHeatmap(w, exportFormat, stats)
+		}
+	case "challenges":
+		write = func(w io.Writer) error {
+			var challenges []storage.Challenge
+			for _, c := range storage.NewDailyChallenges(root).Challenges {
+				if *since == "" || c.Date >= *since {
+					challenges = append(challenges, c)
+				}
+			}
+			return storage.ExportChallenges(w, exportFormat, challenges)
+		}
+	default:
+		return fmt.Errorf("unknown data %q (want %s)", *what, dataKinds)
+	}
+
+	if *output == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	// A failed close can mean the data never reached the disk
+	return f.Close()
+}
+
+// runImport merges a file written by export back into the profile
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: ktype import [flags] FILE (or - for stdin)")
+		fs.PrintDefaults()
+	}
+	what := fs.String("what", "scores", "data to import: "+dataKinds)
+	format := fs.String("format", "", "csv or json (default from the file name, else csv)")
+	dataDir := fs.String("data-dir", "", "keep all ktype files in this directory (default $KTYPE_HOME or the XDG directories)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one file to import")
+	}
+	input := fs.Arg(0)
+
+	importFormat, err := formatFor(*format, input)
+	if err != nil {
+		return err
+	}
+	root, err := storage.ResolveRoot(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var read, merged int
+	switch *what {
+	case "scores":
+		scores, err := storage.ImportScores(r, importFormat)
+		if err != nil {
+			return err
+		}
+		read = len(scores)
+		merged, err = storage.NewLeaderboard(root).Merge(scores)
+		if err != nil {
+			return err
+		}
+	case "heatmap":
+		stats, err := storage.ImportHeatmap(r, importFormat)
+		if err != nil {
+			return err
+		}
+		read = len(stats)
+		hm := storage.NewHeatmap(root)
+		defer hm.Close()
+		if merged, err = hm.Merge(stats); err != nil {
+			return err
+		}
+	case "challenges":
+		challenges, err := storage.ImportChallenges(r, importFormat)
+		if err != nil {
+			return err
+		}
+		read = len(challenges)
+		if merged, err = storage.NewDailyChallenges(root).Merge(challenges); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown data %q (want %s)", *what, dataKinds)
+	}
+
+	fmt.Printf("%s: %d read, %d new or updated\n", *what, read, merged)
+	return nil
+}
+
+// formatFor returns the format given by flag, or the one the file name implies
+func formatFor(flag, name string) (storage.ExportFormat, error) {
+	if flag == "" {
+		flag = strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+		if flag != "json" {
+			flag = "csv"
+		}
+	}
+	return storage.ParseExportFormat(flag)
+}
@@ -0,0 +1,67 @@
+// Command ktype is a minimal terminal typing test.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command is a ktype subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands returns all available subcommands
+func commands() []command {
+	return []command{
+		{"play", "start the typing test (default)", runPlay},
+		{"export", "write scores, heatmap or challenges as CSV or JSON", runExport},
+		{"import", "merge an export back into your history", runImport},
+		{"backup", "archive config, history, heatmap, challenges and word lists", runBackup},
+		{"restore", "restore or merge a backup archive", runRestore},
+		{"help", "show this help", runHelp},
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "ktype: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to a subcommand, falling back to play when only flags are given
+func run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runPlay(args)
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	return fmt.Errorf("unknown command %q (see 'ktype help')", args[0])
+}
+
+// runHelp prints the list of subcommands
+func runHelp(args []string) error {
+	fmt.Println("usage: ktype [command] [flags]")
+	fmt.Println()
+	fmt.Println("commands:")
+	for _, cmd := range commands() {
+		fmt.Printf("  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Println()
+	fmt.Println("run 'ktype <command> -h' for the flags of a command")
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"ktype/internal/app"
+	"ktype/internal/words"
+)
+
+// runPlay starts the TUI, optionally jumping straight into a test
+func runPlay(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	mode := fs.String("mode", "", "start a test right away: time:N, words:N, quote:LENGTH or zen")
+	difficulty := fs.String("difficulty", "medium", "word difficulty: easy, medium or hard")
+	complexity := fs.String("complexity", "normal", "word complexity: normal, punctuation, numbers or full")
+	ghost := fs.String("ghost", "", "race a ghost: off, pb or a target WPM (default from settings)")
+	stop := fs.String("stop", "", "stop on error: off, letter or word (default from settings)")
+	wordList := fs.String("wordlist", "", "draw words from a custom word list")
+	order := fs.String("order", "random", "custom word list order: random or sequential")
+	dataDir := fs.String("data-dir", "", "keep all ktype files in this directory (default $KTYPE_HOME or the XDG directories)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	opts := app.DefaultOptions()
+	opts.Mode = *mode
+	opts.Ghost = *ghost
+	opts.Stop = *stop
+	opts.WordList = *wordList
+	opts.DataDir = *dataDir
+
+	var err error
+	if opts.Difficulty, err = words.ParseDifficulty(*difficulty); err != nil {
+		return err
+	}
+	if opts.Complexity, err = words.ParseComplexity(*complexity); err != nil {
+		return err
+	}
+	if opts.WordOrder, err = words.ParseOrder(*order); err != nil {
+		return err
+	}
+
+	m, err := app.NewModel(opts)
+	if err != nil {
+		return err
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err = p.Run()
+	if closeErr := m.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
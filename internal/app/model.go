@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +13,9 @@ import (
 // tickMsg is sent every tick to update the timer
 type tickMsg time.Time
 
+// AdaptiveWords is the length of an adaptive practice test
+const AdaptiveWords = 50
+
 // Model is the Bubble Tea model
 type Model struct {
 	Game        *game.Game
@@ -22,6 +26,28 @@ type Model struct {
 	WantToQuit  bool
 	QuitPressAt time.Time
 
+	// IsPB is set when the last finished game beat the personal best
+	IsPB bool
+
+	// Ghost pacer used for new games
+	GhostMode storage.GhostMode
+	GhostWPM  int
+
+	// Stop-on-error mode used for new games
+	Strictness storage.Strictness
+
+	// StatsFilter narrows the stats screen to one mode, difficulty or
+	// complexity, and StatsWindow to recent tests
+	StatsFilter storage.ScoreFilter
+	StatsWindow storage.TimeWindow
+
+	// HistoryPage is the page of past tests shown, 0 being the most recent
+	HistoryPage int
+
+	// Replay being watched and the screen to return to afterwards
+	Replay       *game.Replay
+	ReplayReturn game.State
+
 	// For custom input
 	CustomInput string
 	InputMode   string
@@ -31,37 +57,144 @@ type Model struct {
 	// For custom word lists
 	WordListManager *storage.WordListManager
 	CurrentWordList string
+	WordOrder       words.Order
 
 	// For heatmap
 	Heatmap *storage.Heatmap
 
+	// Bigram and trigram timing, updated after every test
+	NGrams *storage.NGrams
+
 	// For configuration
 	ConfigManager *storage.ConfigManager
 
 	// Daily challenges
 	Challenges *storage.DailyChallenges
+
+	// Root locates the files everything above is stored in
+	Root storage.Root
+
+	// SettingsStatus reports the outcome of the last settings action, such
+	// as where a backup was written
+	SettingsStatus string
+
+	// Notices are storage warnings (such as a recovered data file) shown on the menu
+	Notices []string
+}
+
+// Options controls how a new model starts up
+type Options struct {
+	Difficulty words.Difficulty
+	Complexity words.Complexity
+
+	// Mode, when set, skips the menu and starts a test right away.
+	// It uses the same format as Game.ModeString (e.g. "time:30").
+	Mode string
+
+	// Ghost overrides the configured ghost pacer: "off", "pb" or a WPM
+	Ghost string
+
+	// Stop overrides the configured strict mode: "off", "letter" or "word"
+	Stop string
+
+	// WordList, when set, selects a custom word list to draw words from
+	WordList  string
+	WordOrder words.Order
+
+	// DataDir, when set, keeps all files in this directory instead of the
+	// default locations (see storage.ResolveRoot)
+	DataDir string
+}
+
+// DefaultOptions returns the options used when ktype is started without flags
+func DefaultOptions() Options {
+	return Options{
+		Difficulty: words.DifficultyMedium,
+		Complexity: words.ComplexityNormal,
+	}
 }
 
 // InitialModel creates the initial model
 func InitialModel() Model {
-	cm := storage.NewConfigManager()
+	m, _ := NewModel(DefaultOptions())
+	return m
+}
 
-	return Model{
+// NewModel creates a model from the given options
+func NewModel(opts Options) (Model, error) {
+	root, err := storage.ResolveRoot(opts.DataDir)
+	if err != nil {
+		return Model{}, err
+	}
+	cm := storage.NewConfigManager(root)
+
+	m := Model{
 		State:           game.StateMenu,
 		Width:           80,
 		Height:          24,
-		Leaderboard:     storage.NewLeaderboard(),
-		Difficulty:      words.DifficultyMedium,
-		Complexity:      words.ComplexityNormal,
-		WordListManager: storage.NewWordListManager(),
-		CurrentWordList: "",
-		Heatmap:         storage.NewHeatmap(),
+		Leaderboard:     storage.NewLeaderboard(root),
+		Difficulty:      opts.Difficulty,
+		Complexity:      opts.Complexity,
+		WordListManager: storage.NewWordListManager(root),
+		CurrentWordList: opts.WordList,
+		WordOrder:       opts.WordOrder,
+		Heatmap:         storage.NewHeatmap(root),
+		NGrams:          storage.NewNGrams(root),
 		ConfigManager:   cm,
-		Challenges:      storage.NewDailyChallenges(),
+		Challenges:      storage.NewDailyChallenges(root),
+		Root:            root,
+		GhostMode:       cm.GetConfig().Ghost,
+		GhostWPM:        cm.GetConfig().GhostWPM,
+		Strictness:      cm.GetConfig().Strictness,
+	}
+	m.Notices = storage.TakeNotices()
+
+	if opts.Ghost != "" {
+		mode, wpm, err := storage.ParseGhost(opts.Ghost)
+		if err != nil {
+			return m, err
+		}
+		m.GhostMode = mode
+		if wpm > 0 {
+			m.GhostWPM = wpm
+		}
+	}
+
+	if opts.Stop != "" {
+		strictness, err := storage.ParseStrictness(opts.Stop)
+		if err != nil {
+			return m, err
+		}
+		m.Strictness = strictness
+	}
+
+	if opts.WordList != "" && m.WordListManager.GetList(opts.WordList) == nil {
+		return m, fmt.Errorf("word list %q not found", opts.WordList)
+	}
+
+	if opts.Mode != "" {
+		g, err := game.NewFromModeString(opts.Mode, m.Difficulty, m.Complexity, m.Heatmap)
+		if err != nil {
+			return m, err
+		}
+		m.prepareGame(g)
+		g.Ghost = m.newGhost(g)
+		m.Game = g
+		m.State = game.StatePlaying
 	}
+
+	return m, nil
+}
+
+// Close flushes pending data to disk. Call it once the program has exited.
+func (m Model) Close() error {
+	return m.Heatmap.Close()
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
+	if m.State == game.StatePlaying {
+		return tickCmd()
+	}
 	return nil
 }
@@ -1,11 +1,13 @@
 package app
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"ktype/internal/game"
+	"ktype/internal/keyboard"
 	"ktype/internal/storage"
 	"ktype/internal/ui"
 	"ktype/internal/words"
@@ -31,21 +33,129 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.Game != nil && m.State == game.StatePlaying {
 			m.Game.Update()
 			if m.Game.State == game.StateFinished {
-				m.State = game.StateFinished
-				// Save score to leaderboard
-				m.Leaderboard.AddScore(m.Game.WPM(), m.Game.Accuracy(), m.Game.ModeString())
-				// Update challenges progress
-				m.Challenges.UpdateProgress(m.Game.WPM(), m.Game.Accuracy(), len(m.Game.TypedWords))
-				return m, nil
+				return m.finishGame(), nil
 			}
 			return m, tickCmd()
 		}
+
+		if m.Replay != nil && m.State == game.StateReplay {
+			m.Replay.Advance(time.Time(msg))
+			return m, tickCmd()
+		}
 		return m, nil
 	}
 
 	return m, nil
 }
 
+// startGame switches to the playing screen for a new game
+func (m Model) startGame(g *game.Game) (tea.Model, tea.Cmd) {
+	m.prepareGame(g)
+	m.Game = g
+	m.Game.Ghost = m.newGhost(g)
+	m.State = game.StatePlaying
+	m.WantToQuit = false
+	return m, tickCmd()
+}
+
+// startAdaptive starts a practice test drawn from the built-in words, weighted
+// toward the keys and n-grams the heatmap currently shows as weakest
+func (m Model) startAdaptive() (tea.Model, tea.Cmd) {
+	g := game.NewWords(AdaptiveWords, m.Difficulty, m.Complexity, m.Heatmap)
+	targets := game.AdaptiveTargets(m.Heatmap, m.NGrams)
+	g.UseAdaptive(words.NewAdaptiveSource(words.GetList(m.Difficulty), m.Complexity, targets))
+	return m.startGame(g)
+}
+
+// prepareGame applies the session's strict mode, layout emulation and custom
+// word list to a new game. Quotes and adaptive practice always keep their own words.
+func (m Model) prepareGame(g *game.Game) {
+	g.Strictness = m.Strictness
+	if layout, ok := m.emulatedLayout(); ok {
+		g.Emulation = keyboard.NewEmulation(layout)
+	}
+	if m.CurrentWordList == "" || g.Mode == game.ModeQuote || g.Adaptive != nil {
+		return
+	}
+	list := m.WordListManager.GetList(m.CurrentWordList)
+	if list == nil {
+		return
+	}
+	g.UseWordList(words.NewListSource(list.Name, list.Words, m.WordOrder, g.Complexity))
+}
+
+// emulatedLayout returns the layout new games emulate, if emulation is on
+// and the layout isn't QWERTY itself
+func (m Model) emulatedLayout() (keyboard.Layout, bool) {
+	layout := m.ConfigManager.GetLayout()
+	if !m.ConfigManager.GetConfig().Emulate || layout.Name == keyboard.QWERTY.Name {
+		return keyboard.Layout{}, false
+	}
+	return layout, true
+}
+
+// findPB returns the personal best a new test of mode (e.g. "time:30") would
+// be measured against with the session's current settings
+func (m Model) findPB(mode string) *storage.Score {
+	settings := game.Settings{
+		Difficulty: m.Difficulty,
+		Complexity: m.Complexity,
+		WordList:   m.CurrentWordList,
+		Strictness: m.Strictness,
+	}
+	if layout, ok := m.emulatedLayout(); ok {
+		settings.Emulated = layout.Name
+	}
+	return m.Leaderboard.FindPB(settings.PBFilter(mode))
+}
+
+// newGhost creates the ghost pacer for a game according to the ghost setting
+func (m Model) newGhost(g *game.Game) *game.Ghost {
+	switch m.GhostMode {
+	case storage.GhostPace:
+		return game.NewPaceGhost(m.GhostWPM)
+	case storage.GhostPB:
+		pb := m.Leaderboard.FindPB(g.PBFilter())
+		if pb == nil {
+			return nil
+		}
+		if log, err := game.LoadKeyLog(m.Root.KeyLogPath(storage.KeyLogID(pb.Date))); err == nil {
+			return game.NewReplayGhost(log, "pb")
+		}
+		// No recording of the PB, so pace it at its WPM instead
+		ghost := game.NewPaceGhost(pb.WPM)
+		ghost.Label = fmt.Sprintf("pb (%d wpm)", pb.WPM)
+		return ghost
+	}
+	return nil
+}
+
+// finishGame records the result of the current game and shows the results screen
+func (m Model) finishGame() Model {
+	m.State = game.StateFinished
+	m.IsPB = m.Leaderboard.BeatsPB(m.Game.WPM(), m.Game.PBFilter())
+
+	// Save score to leaderboard
+	score, err := m.Leaderboard.AddScore(m.Game.Score())
+	if err != nil {
+		m.Notices = append(m.Notices, fmt.Sprintf("score not saved: %v", err))
+	}
+	// Update challenges progress
+	m.Challenges.UpdateProgress(m.Game.WPM(), m.Game.Accuracy(), len(m.Game.TypedWords))
+
+	if m.ConfigManager.GetConfig().SaveKeyLogs {
+		if err := m.Game.KeyLog().Save(m.Root.KeyLogPath(storage.KeyLogID(score.Date))); err != nil {
+			m.Notices = append(m.Notices, fmt.Sprintf("keystroke log not saved: %v", err))
+		}
+	}
+
+	// Persist this test's keystrokes now rather than waiting for the next flush
+	m.Heatmap.Flush()
+	m.NGrams.Record(m.Game.KeyLog().NGrams())
+
+	return m
+}
+
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Handle ctrl+c globally
 	if msg.String() == "ctrl+c" {
@@ -66,6 +176,8 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleComplexitySelectKey(msg)
 	case game.StateStats:
 		return m.handleStatsKey(msg)
+	case game.StateNGrams:
+		return m.handleNGramsKey(msg)
 	case game.StateHeatmap:
 		return m.handleHeatmapKey(msg)
 	case game.StateSettings:
@@ -80,12 +192,18 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleTimeSelectKey(msg)
 	case game.StateWordsSelect:
 		return m.handleWordsSelectKey(msg)
+	case game.StateQuoteSelect:
+		return m.handleQuoteSelectKey(msg)
 	case game.StateCustomInput:
 		return m.handleCustomInputKey(msg)
 	case game.StatePlaying:
 		return m.handlePlayingKey(msg)
 	case game.StateFinished:
 		return m.handleFinishedKey(msg)
+	case game.StateHistory:
+		return m.handleHistoryKey(msg)
+	case game.StateReplay:
+		return m.handleReplayKey(msg)
 	case game.StateChallenges:
 		return m.handleChallengesKey(msg)
 	}
@@ -93,25 +211,27 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleMenuKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Notices have been seen once the user does anything on the menu
+	m.Notices = nil
+
 	switch msg.String() {
 	case "1": // Quick start 30s
-		m.Game = game.NewTimed(30*time.Second, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewTimed(30*time.Second, m.Difficulty, m.Complexity, m.Heatmap))
 	case "2": // Quick start 50 words
-		m.Game = game.NewWords(50, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewWords(50, m.Difficulty, m.Complexity, m.Heatmap))
 	case "3": // Zen mode
-		m.Game = game.NewZen(m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewZen(m.Difficulty, m.Complexity, m.Heatmap))
+	case "a": // Adaptive practice on the current weak spots
+		return m.startAdaptive()
 	case "t":
 		m.State = game.StateTimeSelect
 		return m, nil
 	case "w":
 		m.State = game.StateWordsSelect
 		return m, nil
+	case "q":
+		m.State = game.StateQuoteSelect
+		return m, nil
 	case "d":
 		m.State = game.StateDifficultySelect
 		return m, nil
@@ -132,6 +252,7 @@ func (m Model) handleMenuKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case ",":
 		m.State = game.StateSettings
+		m.SettingsStatus = ""
 		return m, nil
 	case "esc":
 		if m.WantToQuit {
@@ -195,6 +316,116 @@ func (m Model) handleStatsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc":
 		m.State = game.StateMenu
 		return m, nil
+	case "r":
+		m.State = game.StateHistory
+		m.HistoryPage = 0
+		return m, nil
+	case "n":
+		m.State = game.StateNGrams
+		return m, nil
+	case "m":
+		m.StatsFilter.Mode = nextFilter(m.Leaderboard.Modes(), m.StatsFilter.Mode)
+		return m, nil
+	case "d":
+		m.StatsFilter.Difficulty = nextFilter([]string{"easy", "medium", "hard"}, m.StatsFilter.Difficulty)
+		return m, nil
+	case "c":
+		m.StatsFilter.Complexity = nextFilter([]string{"normal", "punctuation", "numbers", "full"}, m.StatsFilter.Complexity)
+		return m, nil
+	case "w":
+		// Cycle all -> 7d -> 30d
+		m.StatsWindow = (m.StatsWindow + 1) % 3
+		return m, nil
+	}
+	return m, nil
+}
+
+// nextFilter returns the option after current, where "" (no filter) comes
+// before the first and after the last
+func nextFilter(options []string, current string) string {
+	if current == "" && len(options) > 0 {
+		return options[0]
+	}
+	for i, option := range options {
+		if option == current && i+1 < len(options) {
+			return options[i+1]
+		}
+	}
+	return ""
+}
+
+func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.State = game.StateStats
+		return m, nil
+	case "left", "h":
+		if m.HistoryPage > 0 {
+			m.HistoryPage--
+		}
+		return m, nil
+	case "right", "l":
+		if m.HistoryPage < m.Leaderboard.Pages(ui.HistoryPageSize)-1 {
+			m.HistoryPage++
+		}
+		return m, nil
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		index, _ := strconv.Atoi(msg.String())
+		recent := m.Leaderboard.Page(m.HistoryPage, ui.HistoryPageSize)
+		if index > len(recent) {
+			return m, nil
+		}
+		log, err := game.LoadKeyLog(m.Root.KeyLogPath(storage.KeyLogID(recent[index-1].Date)))
+		if err != nil {
+			return m, nil
+		}
+		return m.startReplay(log, game.StateHistory)
+	}
+	return m, nil
+}
+
+// startReplay switches to the replay viewer for a key log
+func (m Model) startReplay(log *game.KeyLog, returnTo game.State) (tea.Model, tea.Cmd) {
+	m.Replay = game.NewReplay(log)
+	m.ReplayReturn = returnTo
+	m.State = game.StateReplay
+	m.WantToQuit = false
+	return m, tickCmd()
+}
+
+func (m Model) handleReplayKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Replay = nil
+		m.State = m.ReplayReturn
+		return m, nil
+	case " ":
+		m.Replay.TogglePause()
+		return m, nil
+	case "left":
+		m.Replay.Seek(m.Replay.Position - 2*time.Second)
+		return m, nil
+	case "right":
+		m.Replay.Seek(m.Replay.Position + 2*time.Second)
+		return m, nil
+	case "+", "=":
+		m.Replay.SetSpeed(m.Replay.Speed * 2)
+		return m, nil
+	case "-":
+		m.Replay.SetSpeed(m.Replay.Speed / 2)
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m Model) handleNGramsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.State = game.StateStats
+		return m, nil
+	case "r":
+		m.NGrams.Clear()
+		return m, nil
 	}
 	return m, nil
 }
@@ -233,6 +464,17 @@ func (m Model) handleCustomWordListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.CurrentWordList = ""
 		}
 		return m, nil
+	case "c":
+		// Go back to the built-in words
+		m.CurrentWordList = ""
+		return m, nil
+	case "o":
+		if m.WordOrder == words.OrderRandom {
+			m.WordOrder = words.OrderSequential
+		} else {
+			m.WordOrder = words.OrderRandom
+		}
+		return m, nil
 	}
 	return m, nil
 }
@@ -240,17 +482,11 @@ func (m Model) handleCustomWordListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleTimeSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "1":
-		m.Game = game.NewTimed(15*time.Second, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewTimed(15*time.Second, m.Difficulty, m.Complexity, m.Heatmap))
 	case "2":
-		m.Game = game.NewTimed(30*time.Second, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewTimed(30*time.Second, m.Difficulty, m.Complexity, m.Heatmap))
 	case "3":
-		m.Game = game.NewTimed(60*time.Second, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewTimed(60*time.Second, m.Difficulty, m.Complexity, m.Heatmap))
 	case "c":
 		m.CustomInput = ""
 		m.InputMode = "time"
@@ -267,21 +503,13 @@ func (m Model) handleTimeSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleWordsSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "1":
-		m.Game = game.NewWords(10, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewWords(10, m.Difficulty, m.Complexity, m.Heatmap))
 	case "2":
-		m.Game = game.NewWords(25, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewWords(25, m.Difficulty, m.Complexity, m.Heatmap))
 	case "3":
-		m.Game = game.NewWords(50, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewWords(50, m.Difficulty, m.Complexity, m.Heatmap))
 	case "4":
-		m.Game = game.NewWords(100, m.Difficulty, m.Complexity, m.Heatmap)
-		m.State = game.StatePlaying
-		return m, tickCmd()
+		return m.startGame(game.NewWords(100, m.Difficulty, m.Complexity, m.Heatmap))
 	case "c":
 		m.CustomInput = ""
 		m.InputMode = "words"
@@ -295,6 +523,23 @@ func (m Model) handleWordsSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) handleQuoteSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "1":
+		return m.startGame(game.NewQuote(words.QuoteShort, m.Heatmap))
+	case "2":
+		return m.startGame(game.NewQuote(words.QuoteMedium, m.Heatmap))
+	case "3":
+		return m.startGame(game.NewQuote(words.QuoteLong, m.Heatmap))
+	case "4":
+		return m.startGame(game.NewQuote(words.QuoteGrind, m.Heatmap))
+	case "esc":
+		m.State = game.StateMenu
+		return m, nil
+	}
+	return m, nil
+}
+
 func (m Model) handleCustomInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyEsc:
@@ -316,14 +561,11 @@ func (m Model) handleCustomInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				if m.InputMode == "words" && value > 1000 {
 					return m, nil // Max 1000 words
 				}
+				m.CustomInput = ""
 				if m.InputMode == "time" {
-					m.Game = game.NewTimed(time.Duration(value)*time.Second, m.Difficulty, m.Complexity, m.Heatmap)
-				} else {
-					m.Game = game.NewWords(value, m.Difficulty, m.Complexity, m.Heatmap)
+					return m.startGame(game.NewTimed(time.Duration(value)*time.Second, m.Difficulty, m.Complexity, m.Heatmap))
 				}
-				m.State = game.StatePlaying
-				m.CustomInput = ""
-				return m, tickCmd()
+				return m.startGame(game.NewWords(value, m.Difficulty, m.Complexity, m.Heatmap))
 			}
 		}
 		return m, nil
@@ -377,8 +619,7 @@ func (m Model) handlePlayingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.Game.HandleSpace()
 			// Check if game finished (words mode)
 			if m.Game.State == game.StateFinished {
-				m.State = game.StateFinished
-				m.Leaderboard.AddScore(m.Game.WPM(), m.Game.Accuracy(), m.Game.ModeString())
+				m = m.finishGame()
 			}
 		}
 		return m, nil
@@ -386,6 +627,9 @@ func (m Model) handlePlayingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.WantToQuit = false
 		if m.Game != nil && len(msg.Runes) > 0 {
 			for _, r := range msg.Runes {
+				if m.Game.Emulation != nil {
+					r = m.Game.Emulation.Translate(r)
+				}
 				m.Game.HandleChar(r)
 			}
 		}
@@ -396,6 +640,8 @@ func (m Model) handlePlayingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleFinishedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "r":
+		return m.startReplay(m.Game.KeyLog(), game.StateFinished)
 	case "tab", "enter":
 		m.Game = nil
 		m.State = game.StateMenu
@@ -432,6 +678,53 @@ func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "2":
 		m.State = game.StateColorSelect
 		return m, nil
+	case "3":
+		m.ConfigManager.SetSaveKeyLogs(!m.ConfigManager.GetConfig().SaveKeyLogs)
+		return m, nil
+	case "4":
+		// Cycle off -> pb -> pace
+		m.GhostMode = (m.GhostMode + 1) % 3
+		m.ConfigManager.SetGhost(m.GhostMode, m.GhostWPM)
+		return m, nil
+	case "5":
+		// Cycle the pace ghost speed in steps of 20 wpm
+		m.GhostWPM += 20
+		if m.GhostWPM > 160 {
+			m.GhostWPM = 40
+		}
+		m.ConfigManager.SetGhost(m.GhostMode, m.GhostWPM)
+		return m, nil
+	case "6":
+		// Cycle off -> stop on letter -> stop on word
+		m.Strictness = (m.Strictness + 1) % 3
+		m.ConfigManager.SetStrictness(m.Strictness)
+		return m, nil
+	case "7":
+		// Write pending keystrokes first so the backup has them
+		m.Heatmap.Flush()
+		path := m.Root.BackupPath(time.Now())
+		if _, err := m.Root.SaveBackup(path); err != nil {
+			m.SettingsStatus = fmt.Sprintf("backup failed: %v", err)
+		} else {
+			m.SettingsStatus = "backed up to " + path
+		}
+		return m, nil
+	case "8":
+		// Cycle through the built-in layouts, then the custom ones
+		layouts := m.ConfigManager.Layouts()
+		current := m.ConfigManager.GetLayout().Name
+		next := layouts[0]
+		for i, l := range layouts {
+			if l.Name == current {
+				next = layouts[(i+1)%len(layouts)]
+				break
+			}
+		}
+		m.ConfigManager.SetLayout(next.Name)
+		return m, nil
+	case "9":
+		m.ConfigManager.SetEmulate(!m.ConfigManager.GetConfig().Emulate)
+		return m, nil
 	}
 	return m, nil
 }
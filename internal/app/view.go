@@ -1,6 +1,8 @@
 package app
 
 import (
+	"time"
+
 	"ktype/internal/game"
 	"ktype/internal/storage"
 	"ktype/internal/ui"
@@ -10,28 +12,34 @@ import (
 func (m Model) View() string {
 	switch m.State {
 	case game.StateMenu:
-		return ui.RenderMainMenu(m.Leaderboard, m.Width, m.Height, m.WantToQuit, m.Difficulty, m.Complexity)
+		return ui.RenderMainMenu(m.findPB, m.Width, m.Height, m.WantToQuit, m.Difficulty, m.Complexity, m.CurrentWordList, m.WordOrder, m.Notices)
 	case game.StateDifficultySelect:
 		return ui.RenderDifficultySelect(m.Difficulty, m.Width, m.Height, m.WantToQuit)
 	case game.StateComplexitySelect:
 		return ui.RenderComplexitySelect(m.Complexity, m.Width, m.Height, m.WantToQuit)
 	case game.StateStats:
-		stats := storage.NewStatistics(m.Leaderboard)
-		return ui.RenderStats(stats, m.Width, m.Height, m.WantToQuit)
+		filter := m.StatsFilter
+		filter.Since = m.StatsWindow.Since(time.Now())
+		all := storage.NewStatistics(m.Leaderboard).WithHeatmap(m.Heatmap, m.ConfigManager.GetLayout())
+		return ui.RenderStats(all.Where(filter), all.GetFingerBreakdown(), m.StatsFilter, m.StatsWindow, m.Width, m.Height, m.WantToQuit)
+	case game.StateNGrams:
+		return ui.RenderNGrams(m.NGrams, m.Width, m.Height, m.WantToQuit)
 	case game.StateHeatmap:
-		return ui.RenderHeatmap(m.Heatmap, m.Width, m.Height, m.WantToQuit)
+		return ui.RenderHeatmap(m.Heatmap, m.ConfigManager.GetLayout(), m.Width, m.Height, m.WantToQuit)
 	case game.StateSettings:
-		return ui.RenderSettings(m.ConfigManager, m.Width, m.Height, m.WantToQuit)
+		return ui.RenderSettings(m.ConfigManager, m.SettingsStatus, m.Width, m.Height, m.WantToQuit)
 	case game.StateCursorSelect:
 		return ui.RenderCursorSelect(m.ConfigManager, m.Width, m.Height, m.WantToQuit)
 	case game.StateColorSelect:
 		return ui.RenderColorSelect(m.ConfigManager, m.Width, m.Height, m.WantToQuit)
 	case game.StateCustomWordList:
-		return ui.RenderCustomWordList(m.WordListManager, m.CurrentWordList, m.Width, m.Height, m.WantToQuit)
+		return ui.RenderCustomWordList(m.WordListManager, m.CurrentWordList, m.WordOrder, m.Width, m.Height, m.WantToQuit)
 	case game.StateTimeSelect:
-		return ui.RenderTimeSelect(m.Leaderboard, m.Width, m.Height, m.WantToQuit)
+		return ui.RenderTimeSelect(m.findPB, m.Width, m.Height, m.WantToQuit)
 	case game.StateWordsSelect:
-		return ui.RenderWordsSelect(m.Leaderboard, m.Width, m.Height, m.WantToQuit)
+		return ui.RenderWordsSelect(m.findPB, m.Width, m.Height, m.WantToQuit)
+	case game.StateQuoteSelect:
+		return ui.RenderQuoteSelect(m.findPB, m.Width, m.Height, m.WantToQuit)
 	case game.StateCustomInput:
 		return ui.RenderCustomInput(m.CustomInput, m.InputMode, m.Width, m.Height, m.ConfigManager.GetCursorType().CursorChar())
 	case game.StatePlaying:
@@ -40,8 +48,13 @@ func (m Model) View() string {
 		}
 	case game.StateFinished:
 		if m.Game != nil {
-			isPB := m.Leaderboard.IsPB(m.Game.WPM(), m.Game.ModeString())
-			return ui.RenderFinished(m.Game, m.Width, m.Height, isPB, m.WantToQuit)
+			return ui.RenderFinished(m.Game, m.ConfigManager.GetLayout(), m.Width, m.Height, m.IsPB, m.WantToQuit)
+		}
+	case game.StateHistory:
+		return ui.RenderHistory(m.Leaderboard, m.Root, m.HistoryPage, m.Width, m.Height, m.WantToQuit)
+	case game.StateReplay:
+		if m.Replay != nil {
+			return ui.RenderReplay(m.Replay, m.Width, m.Height, m.ConfigManager.GetCursorType().CursorChar())
 		}
 	case game.StateChallenges:
 		return ui.RenderChallenges(m.Challenges, m.Width, m.Height, m.WantToQuit)
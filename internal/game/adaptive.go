@@ -0,0 +1,92 @@
+package game
+
+import (
+	"math"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"ktype/internal/storage"
+	"ktype/internal/words"
+)
+
+const (
+	// adaptiveKeys and adaptiveNGrams cap how many weak keys, and weak
+	// n-grams of each size and kind, adaptive practice targets at once
+	adaptiveKeys   = 8
+	adaptiveNGrams = 4
+
+	// minAdaptiveHits is how often a key must have been typed before its
+	// error rate is trusted
+	minAdaptiveHits = 20
+)
+
+// AdaptiveTargets picks what adaptive practice should focus on: the letters
+// with the highest error rates in the heatmap, and the bigrams and trigrams
+// that are most error-prone or much slower than usual. Either source may be
+// nil. Each kind is weighted relative to its worst entry, so the targets
+// shift as the typist improves.
+func AdaptiveTargets(hm *storage.Heatmap, ng *storage.NGrams) []words.Target {
+	var targets []words.Target
+
+	if hm != nil {
+		var keys []*storage.KeyStats
+		for _, stat := range hm.GetTopErrors(math.MaxInt) {
+			r, _ := utf8.DecodeRuneInString(stat.Key)
+			if stat.TotalHits >= minAdaptiveHits && utf8.RuneCountInString(stat.Key) == 1 && unicode.IsLetter(r) {
+				keys = append(keys, stat)
+			}
+		}
+		if len(keys) > adaptiveKeys {
+			keys = keys[:adaptiveKeys]
+		}
+		for _, stat := range keys {
+			targets = append(targets, words.Target{Text: stat.Key, Weight: stat.ErrorRate() / keys[0].ErrorRate()})
+		}
+	}
+
+	if ng != nil {
+		for _, n := range NGramSizes {
+			errors := ng.GetTopErrors(n, adaptiveNGrams)
+			for _, stat := range errors {
+				targets = append(targets, words.Target{Text: stat.NGram, Weight: stat.ErrorRate() / errors[0].ErrorRate()})
+			}
+			targets = append(targets, slowNGrams(ng, n)...)
+		}
+	}
+
+	// Strongest first, so the most important targets are listed first
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Weight > targets[j].Weight
+	})
+	return targets
+}
+
+// slowNGrams returns the slowest n-grams of length n that take longer than
+// the median. An n-gram twice as slow as the median gets full weight.
+func slowNGrams(ng *storage.NGrams, n int) []words.Target {
+	all := ng.GetSlowest(n, len(ng.Stats))
+	if len(all) < 2 {
+		return nil
+	}
+	median := all[len(all)/2].AverageLatency()
+	if median <= 0 {
+		return nil
+	}
+
+	var targets []words.Target
+	for _, stat := range all[:min(adaptiveNGrams, len(all))] {
+		weight := float64(stat.AverageLatency())/float64(median) - 1
+		if weight <= 0 {
+			break
+		}
+		targets = append(targets, words.Target{Text: stat.NGram, Weight: min(weight, 1)})
+	}
+	return targets
+}
+
+// UseAdaptive replaces the generated words with words drawn from an adaptive source
+func (g *Game) UseAdaptive(source *words.AdaptiveSource) {
+	g.Adaptive = source
+	g.Words = source.Next(len(g.Words))
+}
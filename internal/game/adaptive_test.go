@@ -0,0 +1,89 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"ktype/internal/storage"
+	"ktype/internal/words"
+)
+
+func TestAdaptiveTargets(t *testing.T) {
+	root := storage.NewRoot(t.TempDir())
+	hm := storage.NewHeatmap(root)
+	defer hm.Close()
+
+	// e: 10% errors, x: 40% errors, q: typed too rarely to trust, ;: not a letter
+	for i := 0; i < 50; i++ {
+		hm.RecordHit("e")
+		hm.RecordHit("x")
+		hm.RecordHit(";")
+	}
+	for i := 0; i < 5; i++ {
+		hm.RecordError("e")
+	}
+	for i := 0; i < 20; i++ {
+		hm.RecordError("x")
+		hm.RecordError(";")
+	}
+	hm.RecordHit("q")
+	hm.RecordError("q")
+
+	ng := storage.NewNGrams(root)
+	var samples []storage.NGramSample
+	for i := 0; i < 10; i++ {
+		samples = append(samples,
+			storage.NGramSample{NGram: "th", Latency: 100 * time.Millisecond},
+			storage.NGramSample{NGram: "he", Latency: 100 * time.Millisecond},
+			storage.NGramSample{NGram: "ck", Latency: 300 * time.Millisecond, Error: i < 3},
+		)
+	}
+	if err := ng.Record(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	weights := make(map[string]float64)
+	for _, target := range AdaptiveTargets(hm, ng) {
+		weights[target.Text] = target.Weight
+	}
+
+	if weights["x"] != 1 || weights["e"] != 0.25 {
+		t.Errorf("Expected x at full weight and e at a quarter, got %v", weights)
+	}
+	if _, ok := weights["q"]; ok {
+		t.Error("Rarely typed keys should not be targeted")
+	}
+	if _, ok := weights[";"]; ok {
+		t.Error("Only letters should be targeted")
+	}
+	if weights["ck"] != 1 {
+		t.Errorf("Expected ck, slow and error-prone, at full weight, got %v", weights)
+	}
+	if _, ok := weights["th"]; ok {
+		t.Error("N-grams at the median speed should not be targeted")
+	}
+}
+
+func TestAdaptiveTargetsNoData(t *testing.T) {
+	if targets := AdaptiveTargets(nil, nil); len(targets) != 0 {
+		t.Errorf("Expected no targets without data, got %v", targets)
+	}
+}
+
+func TestUseAdaptive(t *testing.T) {
+	g := NewWords(25, words.DifficultyMedium, words.ComplexityNormal, nil)
+	count := len(g.Words)
+	g.UseAdaptive(words.NewAdaptiveSource([]string{"alpha", "beta"}, words.ComplexityNormal, nil))
+
+	if len(g.Words) != count {
+		t.Errorf("Expected %d words, got %d", count, len(g.Words))
+	}
+	for _, w := range g.Words {
+		if w != "alpha" && w != "beta" {
+			t.Fatalf("Unexpected word %q from the adaptive source", w)
+		}
+	}
+	if mode := g.ModeString(); mode != "words:25/adaptive" {
+		t.Errorf("ModeString() = %q, expected words:25/adaptive", mode)
+	}
+}
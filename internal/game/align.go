@@ -0,0 +1,124 @@
+package game
+
+import "time"
+
+// alignWord compares a submitted word with the expected word and classifies every
+// difference as a substitution, insertion, omission or adjacent transposition.
+// It uses the optimal string alignment variant of the Damerau-Levenshtein distance
+// over runes, so the returned errors are the fewest edits that explain the input.
+// Positions are rune indexes into the typed word; a missing character is reported
+// at the index where it should have been typed.
+func alignWord(typed, expected string, wordIndex int, timestamp time.Time) []TypingError {
+	a := []rune(typed)
+	b := []rune(expected)
+	n, m := len(a), len(b)
+
+	d := make([][]int, n+1)
+	for i := range d {
+		d[i] = make([]int, m+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	// Walk back from the end, preferring matches, then swaps, substitutions,
+	// extra characters and finally missing ones
+	var reversed []TypingError
+	newError := func(errorType ErrorType, expected, typed rune, position int) {
+		reversed = append(reversed, TypingError{
+			ExpectedChar: expected,
+			TypedChar:    typed,
+			Position:     position,
+			WordIndex:    wordIndex,
+			Timestamp:    timestamp,
+			ErrorType:    errorType,
+		})
+	}
+
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && a[i-1] == b[j-1] && d[i][j] == d[i-1][j-1]:
+			i, j = i-1, j-1
+		case i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && a[i-1] != a[i-2] && d[i][j] == d[i-2][j-2]+1:
+			newError(ErrorTransposition, b[j-2], a[i-2], i-2)
+			i, j = i-2, j-2
+		case i > 0 && j > 0 && d[i][j] == d[i-1][j-1]+1:
+			newError(ErrorWrongChar, b[j-1], a[i-1], i-1)
+			i, j = i-1, j-1
+		case i > 0 && d[i][j] == d[i-1][j]+1:
+			newError(ErrorExtraChar, 0, a[i-1], i-1)
+			i--
+		default:
+			newError(ErrorMissingChar, b[j-1], 0, i)
+			j--
+		}
+	}
+
+	errors := make([]TypingError, len(reversed))
+	for k, err := range reversed {
+		errors[len(reversed)-1-k] = err
+	}
+	return errors
+}
+
+// submitWordErrors replaces the provisional errors recorded while typing the current
+// word with the aligned errors of the submitted input. Mistakes that were fixed with
+// backspace are kept as corrected errors.
+func (g *Game) submitWordErrors(typed, expected string) {
+	kept := g.Errors[:g.wordErrorStart]
+	for _, err := range g.Errors[g.wordErrorStart:] {
+		if err.Corrected {
+			kept = append(kept, err)
+		}
+	}
+	g.Errors = append(kept, alignWord(typed, expected, g.WordIndex, time.Now())...)
+	g.wordErrorStart = len(g.Errors)
+}
+
+// correctError marks the provisional error at a position of the current word as
+// fixed after the character there is deleted
+func (g *Game) correctError(position int) {
+	for i := len(g.Errors) - 1; i >= g.wordErrorStart; i-- {
+		if g.Errors[i].Position == position && !g.Errors[i].Corrected {
+			g.Errors[i].Corrected = true
+			return
+		}
+	}
+}
+
+// ErrorsByType counts the uncorrected errors of each type
+func (g *Game) ErrorsByType() map[ErrorType]int {
+	counts := make(map[ErrorType]int)
+	for _, err := range g.Errors {
+		if !err.Corrected {
+			counts[err.ErrorType]++
+		}
+	}
+	return counts
+}
+
+// CorrectedErrors returns the number of mistakes fixed with backspace
+func (g *Game) CorrectedErrors() int {
+	count := 0
+	for _, err := range g.Errors {
+		if err.Corrected {
+			count++
+		}
+	}
+	return count
+}
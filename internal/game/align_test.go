@@ -0,0 +1,103 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlignWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		typed    string
+		expected string
+		errors   []TypingError
+	}{
+		{"exact", "hello", "hello", nil},
+		{"substitution", "hallo", "hello", []TypingError{
+			{ExpectedChar: 'e', TypedChar: 'a', Position: 1, ErrorType: ErrorWrongChar},
+		}},
+		{"insertion", "helllo", "hello", []TypingError{
+			{ExpectedChar: 0, TypedChar: 'l', Position: 2, ErrorType: ErrorExtraChar},
+		}},
+		{"omission", "helo", "hello", []TypingError{
+			{ExpectedChar: 'l', TypedChar: 0, Position: 2, ErrorType: ErrorMissingChar},
+		}},
+		{"transposition", "teh", "the", []TypingError{
+			{ExpectedChar: 'h', TypedChar: 'e', Position: 1, ErrorType: ErrorTransposition},
+		}},
+		{"skipped word", "", "ab", []TypingError{
+			{ExpectedChar: 'a', Position: 0, ErrorType: ErrorMissingChar},
+			{ExpectedChar: 'b', Position: 0, ErrorType: ErrorMissingChar},
+		}},
+		{"multibyte", "grün", "grun", []TypingError{
+			{ExpectedChar: 'u', TypedChar: 'ü', Position: 2, ErrorType: ErrorWrongChar},
+		}},
+		{"mixed", "hte worl", "the world", []TypingError{
+			{ExpectedChar: 't', TypedChar: 'h', Position: 0, ErrorType: ErrorTransposition},
+			{ExpectedChar: 'd', TypedChar: 0, Position: 8, ErrorType: ErrorMissingChar},
+		}},
+	}
+
+	// Within a run of repeated letters the error is reported at the first of them
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := alignWord(tt.typed, tt.expected, 0, time.Time{})
+			if len(got) != len(tt.errors) {
+				t.Fatalf("alignWord(%q, %q) returned %d errors, expected %d: %+v", tt.typed, tt.expected, len(got), len(tt.errors), got)
+			}
+			for i, want := range tt.errors {
+				if got[i] != want {
+					t.Errorf("error %d = %+v, expected %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitWordClassifiesErrors(t *testing.T) {
+	g := NewWords(3, 0, 0, nil)
+	g.Words = []string{"the", "hello", "cat"}
+
+	for _, c := range "teh" {
+		g.HandleChar(c)
+	}
+	g.HandleSpace()
+
+	// "helo" with a corrected typo on the way
+	for _, c := range "hx" {
+		g.HandleChar(c)
+	}
+	g.HandleBackspace()
+	for _, c := range "elo" {
+		g.HandleChar(c)
+	}
+	g.HandleSpace()
+
+	counts := g.ErrorsByType()
+	if counts[ErrorTransposition] != 1 {
+		t.Errorf("Expected 1 transposition, got %d", counts[ErrorTransposition])
+	}
+	if counts[ErrorMissingChar] != 1 {
+		t.Errorf("Expected 1 missing char, got %d", counts[ErrorMissingChar])
+	}
+	if counts[ErrorWrongChar] != 0 {
+		t.Errorf("Expected the provisional wrong chars to be replaced, got %d", counts[ErrorWrongChar])
+	}
+	if g.CorrectedErrors() != 1 {
+		t.Errorf("Expected 1 corrected error, got %d", g.CorrectedErrors())
+	}
+}
+
+func TestErrorTypeString(t *testing.T) {
+	tests := map[ErrorType]string{
+		ErrorWrongChar:     "wrong",
+		ErrorExtraChar:     "extra",
+		ErrorMissingChar:   "missing",
+		ErrorTransposition: "swapped",
+	}
+	for errorType, expected := range tests {
+		if errorType.String() != expected {
+			t.Errorf("ErrorType(%d).String() = %q, expected %q", errorType, errorType.String(), expected)
+		}
+	}
+}
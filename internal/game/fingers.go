@@ -0,0 +1,50 @@
+package game
+
+import (
+	"strings"
+
+	"ktype/internal/keyboard"
+	"ktype/internal/storage"
+)
+
+// KeyStats adds up the characters typed in the log per key, the way the
+// heatmap records them: keys are lowercased, wrong and extra characters count
+// as errors, and each key is timed from the keystroke before it unless the
+// typist paused for longer than maxNGramGap
+func (l *KeyLog) KeyStats() []storage.KeyStats {
+	var stats []storage.KeyStats
+	index := make(map[string]int)
+
+	for i, e := range l.Events {
+		if e.Action != ActionChar || e.Key == "" {
+			continue
+		}
+
+		key := strings.ToLower(e.Key)
+		at, ok := index[key]
+		if !ok {
+			at = len(stats)
+			index[key] = at
+			stats = append(stats, storage.KeyStats{Key: key})
+		}
+
+		stat := &stats[at]
+		stat.TotalHits++
+		if e.Result != ResultCorrect {
+			stat.ErrorCount++
+		}
+		if i > 0 {
+			if gap := e.Time.Sub(l.Events[i-1].Time); gap <= maxNGramGap {
+				stat.Latency += gap
+				stat.Timed++
+			}
+		}
+	}
+	return stats
+}
+
+// FingerBreakdown groups the keystrokes of the log by the finger and hand
+// that type each key on layout
+func (l *KeyLog) FingerBreakdown(layout keyboard.Layout) storage.FingerBreakdown {
+	return storage.NewFingerBreakdown(l.KeyStats(), layout)
+}
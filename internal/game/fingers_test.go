@@ -0,0 +1,48 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"ktype/internal/keyboard"
+	"ktype/internal/storage"
+)
+
+func TestKeyLogKeyStats(t *testing.T) {
+	l := typed(100*time.Millisecond, "sad", "Fad")
+	last := l.Events[len(l.Events)-1]
+	l.Events = append(l.Events, KeyEvent{Time: last.Time.Add(3 * time.Second), Action: ActionChar, Key: "x", Expected: "", Result: ResultExtra})
+
+	keys := make(map[string]storage.KeyStats)
+	for _, s := range l.KeyStats() {
+		keys[s.Key] = s
+	}
+	if len(keys) != 5 {
+		t.Fatalf("Expected stats for s, a, d, f and x, got %+v", keys)
+	}
+
+	a := keys["a"]
+	if a.TotalHits != 2 || a.Timed != 2 || a.AverageLatency() != 100*time.Millisecond {
+		t.Errorf("Expected a typed twice at 100ms, got %+v", a)
+	}
+	if s := keys["s"]; s.Timed != 0 {
+		t.Errorf("Expected the first key to be untimed, got %+v", s)
+	}
+	if f := keys["f"]; f.TotalHits != 1 {
+		t.Errorf("Expected F to count as f, got %+v", f)
+	}
+	if x := keys["x"]; x.ErrorCount != 1 || x.Timed != 0 {
+		t.Errorf("Expected an untimed error on x after the pause, got %+v", x)
+	}
+
+	b := l.FingerBreakdown(keyboard.QWERTY)
+	if ring := b.Fingers[keyboard.LeftRing]; ring.Hits != 2 || ring.Errors != 1 {
+		t.Errorf("Expected s and x on the left ring finger, got %+v", ring)
+	}
+	if left, right := b.Hands[keyboard.LeftHand], b.Hands[keyboard.RightHand]; left.Hits != 7 || right.Hits != 0 {
+		t.Errorf("Expected every key on the left hand, got %+v and %+v", left, right)
+	}
+	if b.Unassigned != 0 {
+		t.Errorf("Expected spaces to be left out, got %d unassigned", b.Unassigned)
+	}
+}
@@ -2,9 +2,12 @@ package game
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"ktype/internal/keyboard"
 	"ktype/internal/storage"
 	"ktype/internal/words"
 )
@@ -29,10 +32,44 @@ type Game struct {
 	TotalChars  int
 	ErrorChars  int
 
+	// Quote is the passage being typed in quote mode
+	Quote *words.Quote
+
+	// Source supplies the words when playing from a custom word list
+	Source *words.ListSource
+
+	// Adaptive supplies the words in adaptive practice, weighted toward the
+	// typist's weak keys and n-grams
+	Adaptive *words.AdaptiveSource
+
+	// Strictness makes mistakes block the cursor (letter) or the space (word)
+	Strictness storage.Strictness
+
+	// Emulation, when set, remaps keys from their QWERTY positions to another
+	// layout before they reach HandleChar
+	Emulation *keyboard.Emulation
+
 	Heatmap *storage.Heatmap
 
 	Errors        []TypingError
 	CurrentErrors []int
+
+	// wordErrorStart is the index in Errors where the current word's errors begin
+	wordErrorStart int
+
+	// Keystrokes is the timestamped log of every key handled during the test
+	Keystrokes []KeyEvent
+
+	// Ghost is an optional pacer to race against
+	Ghost *Ghost
+
+	// Timeline holds one sample per second of the test
+	Timeline      []Sample
+	sampledChars  int
+	sampledErrors int
+
+	// now, when set, replaces the wall clock, see clock
+	now func() time.Time
 }
 
 // NewTimed creates a new timed game
@@ -50,6 +87,7 @@ func NewTimed(duration time.Duration, difficulty words.Difficulty, complexity wo
 		Heatmap:       heatmap,
 		Errors:        make([]TypingError, 0),
 		CurrentErrors: make([]int, 0),
+		Keystrokes:    make([]KeyEvent, 0),
 	}
 }
 
@@ -68,6 +106,7 @@ func NewWords(wordCount int, difficulty words.Difficulty, complexity words.Compl
 		Heatmap:       heatmap,
 		Errors:        make([]TypingError, 0),
 		CurrentErrors: make([]int, 0),
+		Keystrokes:    make([]KeyEvent, 0),
 	}
 }
 
@@ -85,18 +124,138 @@ func NewZen(difficulty words.Difficulty, complexity words.Complexity, heatmap *s
 		Heatmap:       heatmap,
 		Errors:        make([]TypingError, 0),
 		CurrentErrors: make([]int, 0),
+		Keystrokes:    make([]KeyEvent, 0),
+	}
+}
+
+// NewQuote creates a new quote mode game from a random quote of the given length
+func NewQuote(length words.QuoteLength, heatmap *storage.Heatmap) *Game {
+	q := words.GetRandomQuote(length)
+	w := strings.Fields(q.Text)
+	return &Game{
+		Words:         w,
+		Correct:       make([]bool, 0),
+		TypedWords:    make([]string, 0),
+		TargetWords:   len(w),
+		Mode:          ModeQuote,
+		State:         StatePlaying,
+		Quote:         &q,
+		Heatmap:       heatmap,
+		Errors:        make([]TypingError, 0),
+		CurrentErrors: make([]int, 0),
+		Keystrokes:    make([]KeyEvent, 0),
 	}
 }
 
+// UseWordList replaces the generated words with words drawn from a custom word list
+func (g *Game) UseWordList(source *words.ListSource) {
+	g.Source = source
+	g.Words = source.Next(len(g.Words))
+}
+
+// moreWords returns n more words from the game's word source
+func (g *Game) moreWords(n int) []string {
+	if g.Source != nil {
+		return g.Source.Next(n)
+	}
+	if g.Adaptive != nil {
+		return g.Adaptive.Next(n)
+	}
+	return words.GetRandomWithComplexity(n, g.Difficulty, g.Complexity)
+}
+
 // ModeString returns a string representation for leaderboard
 func (g *Game) ModeString() string {
-	if g.Mode == ModeTimed {
+	return g.Settings().ModeString(g.baseMode())
+}
+
+// baseMode returns the mode without the settings suffixes, e.g. "time:30"
+func (g *Game) baseMode() string {
+	switch {
+	case g.Mode == ModeTimed:
 		return fmt.Sprintf("time:%d", int(g.Duration.Seconds()))
-	}
-	if g.Mode == ModeWords {
+	case g.Mode == ModeWords:
 		return fmt.Sprintf("words:%d", g.TargetWords)
+	case g.Mode == ModeQuote && g.Quote != nil:
+		return fmt.Sprintf("quote:%s", g.Quote.Length())
+	default:
+		return "zen"
+	}
+}
+
+// Settings returns the game's setup as far as scores are concerned
+func (g *Game) Settings() Settings {
+	s := Settings{
+		Difficulty: g.Difficulty,
+		Complexity: g.Complexity,
+		Adaptive:   g.Adaptive != nil,
+		Strictness: g.Strictness,
+	}
+	if g.Source != nil {
+		s.WordList = g.Source.Name
+	}
+	if g.Emulation != nil {
+		s.Emulated = g.Emulation.Layout.Name
 	}
-	return "zen"
+	return s
+}
+
+// PBFilter returns the filter that finds the personal best this game is
+// measured against: scores of the same mode recorded with the same settings
+func (g *Game) PBFilter() storage.ScoreFilter {
+	return g.Settings().PBFilter(g.baseMode())
+}
+
+// modeOption returns the value of a "/key:value" suffix of a mode string
+func modeOption(mode, key string) string {
+	parts := strings.Split(mode, "/")
+	for _, part := range parts[1:] {
+		if k, v, ok := strings.Cut(part, ":"); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewFromModeString creates a game from a mode string as produced by ModeString
+// (e.g. "time:30", "words:50", "quote:short" or "zen")
+func NewFromModeString(mode string, difficulty words.Difficulty, complexity words.Complexity, heatmap *storage.Heatmap) (*Game, error) {
+	if mode == "zen" {
+		return NewZen(difficulty, complexity, heatmap), nil
+	}
+
+	kind, value, ok := strings.Cut(mode, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid mode %q (want time:N, words:N, quote:LENGTH or zen)", mode)
+	}
+
+	if kind == "quote" {
+		length, err := words.ParseQuoteLength(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %q: %w", mode, err)
+		}
+		return NewQuote(length, heatmap), nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid mode %q: %q is not a positive number", mode, value)
+	}
+
+	switch kind {
+	case "time":
+		if n > 3600 {
+			return nil, fmt.Errorf("invalid mode %q: max duration is 3600 seconds", mode)
+		}
+		return NewTimed(time.Duration(n)*time.Second, difficulty, complexity, heatmap), nil
+	case "words":
+		if n > 1000 {
+			return nil, fmt.Errorf("invalid mode %q: max word count is 1000", mode)
+		}
+		return NewWords(n, difficulty, complexity, heatmap), nil
+	}
+
+	return nil, fmt.Errorf("invalid mode %q (want time:N, words:N, quote:LENGTH or zen)", mode)
 }
 
 // Start begins the game timer
@@ -106,6 +265,15 @@ func (g *Game) Start() {
 	}
 }
 
+// clock returns the current time: the wall clock while playing, the time of
+// the keystroke being played back in a replay
+func (g *Game) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
 // Update updates the elapsed time and checks if game is finished
 func (g *Game) Update() {
 	if g.State != StatePlaying || g.StartTime.IsZero() {
@@ -117,9 +285,12 @@ func (g *Game) Update() {
 	if g.Mode == ModeTimed {
 		if g.Elapsed >= g.Duration {
 			g.Elapsed = g.Duration
-			g.State = StateFinished
+			g.finish()
+			return
 		}
 	}
+
+	g.recordSamples()
 }
 
 // TimeRemaining returns the time remaining in seconds
@@ -136,7 +307,7 @@ func (g *Game) TimeRemaining() int {
 
 // WordsRemaining returns words left to type
 func (g *Game) WordsRemaining() int {
-	if g.Mode != ModeWords {
+	if g.Mode != ModeWords && g.Mode != ModeQuote {
 		return -1
 	}
 	remaining := g.TargetWords - len(g.TypedWords)
@@ -151,7 +322,7 @@ func (g *Game) Progress() string {
 	if g.Mode == ModeTimed {
 		return fmt.Sprintf("%ds", g.TimeRemaining())
 	}
-	if g.Mode == ModeWords {
+	if g.Mode == ModeWords || g.Mode == ModeQuote {
 		return fmt.Sprintf("%d/%d", len(g.TypedWords), g.TargetWords)
 	}
 	return fmt.Sprintf("%d words", len(g.TypedWords))
@@ -166,19 +337,25 @@ func (g *Game) HandleChar(char rune) {
 	g.Start()
 
 	charStr := string(char)
+	previousInput := g.CurrentInput
 	g.CurrentInput += charStr
 	g.TotalChars++
 
-	currentWord := g.Words[g.WordIndex]
-	inputLen := len(g.CurrentInput)
+	// Positions are counted in runes so multibyte letters score as one character
+	currentWord := []rune(g.Words[g.WordIndex])
+	inputLen := utf8.RuneCountInString(g.CurrentInput)
 	isCorrect := true
+	result := ResultCorrect
+	expected := ""
 
 	if inputLen <= len(currentWord) {
-		if g.CurrentInput[inputLen-1] != currentWord[inputLen-1] {
+		expected = string(currentWord[inputLen-1])
+		if char != currentWord[inputLen-1] {
 			g.ErrorChars++
 			isCorrect = false
+			result = ResultWrong
 			err := TypingError{
-				ExpectedChar: rune(currentWord[inputLen-1]),
+				ExpectedChar: currentWord[inputLen-1],
 				TypedChar:    char,
 				Position:     inputLen - 1,
 				WordIndex:    g.WordIndex,
@@ -186,11 +363,11 @@ func (g *Game) HandleChar(char rune) {
 				ErrorType:    ErrorWrongChar,
 			}
 			g.Errors = append(g.Errors, err)
-			g.CurrentErrors = append(g.CurrentErrors, inputLen-1)
 		}
 	} else {
 		g.ErrorChars++
 		isCorrect = false
+		result = ResultExtra
 		err := TypingError{
 			ExpectedChar: 0,
 			TypedChar:    char,
@@ -200,12 +377,27 @@ func (g *Game) HandleChar(char rune) {
 			ErrorType:    ErrorExtraChar,
 		}
 		g.Errors = append(g.Errors, err)
-		g.CurrentErrors = append(g.CurrentErrors, inputLen-1)
 	}
 
-	g.Heatmap.RecordHit(charStr)
 	if !isCorrect {
-		g.Heatmap.RecordError(charStr)
+		if g.Strictness == storage.StrictLetter {
+			// Stop on letter: the mistake is counted but the cursor doesn't
+			// move, so the input has no error to show
+			g.Errors[len(g.Errors)-1].Corrected = true
+			g.CurrentInput = previousInput
+		} else {
+			g.CurrentErrors = append(g.CurrentErrors, inputLen-1)
+		}
+	}
+
+	latency := g.keyLatency()
+	g.recordKey(ActionChar, charStr, expected, inputLen-1, result)
+
+	if g.Heatmap != nil {
+		g.Heatmap.RecordTimedHit(charStr, latency)
+		if !isCorrect {
+			g.Heatmap.RecordError(charStr)
+		}
 	}
 }
 
@@ -220,6 +412,21 @@ func (g *Game) HandleSpace() {
 	currentWord := g.Words[g.WordIndex]
 	isCorrect := g.CurrentInput == currentWord
 
+	if !isCorrect && g.Strictness != storage.StrictOff {
+		// Strict modes only move on once the word is typed correctly
+		g.TotalChars++
+		g.ErrorChars++
+		g.recordKey(ActionSpace, " ", " ", utf8.RuneCountInString(g.CurrentInput), ResultWrong)
+		return
+	}
+
+	result := ResultCorrect
+	if !isCorrect {
+		result = ResultWrong
+	}
+	g.recordKey(ActionSpace, " ", " ", utf8.RuneCountInString(g.CurrentInput), result)
+	g.submitWordErrors(g.CurrentInput, currentWord)
+
 	g.Correct = append(g.Correct, isCorrect)
 	g.TypedWords = append(g.TypedWords, g.CurrentInput)
 	g.TotalChars++
@@ -229,16 +436,15 @@ func (g *Game) HandleSpace() {
 
 	if g.WordIndex >= len(g.Words) {
 		if g.Mode == ModeZen {
-			newWords := words.GetRandomWithComplexity(100, g.Difficulty, g.Complexity)
-			g.Words = append(g.Words, newWords...)
+			g.Words = append(g.Words, g.moreWords(100)...)
 		} else {
-			g.State = StateFinished
+			g.finish()
 			return
 		}
 	}
 
 	if g.Mode == ModeWords && len(g.TypedWords) >= g.TargetWords {
-		g.State = StateFinished
+		g.finish()
 	}
 }
 
@@ -249,7 +455,18 @@ func (g *Game) HandleBackspace() {
 	}
 
 	if len(g.CurrentInput) > 0 {
-		g.CurrentInput = g.CurrentInput[:len(g.CurrentInput)-1]
+		// Remove a whole rune, never a single byte of a multibyte letter
+		input := []rune(g.CurrentInput)
+		position := len(input) - 1
+		deleted := string(input[position])
+		expected := ""
+		if currentWord := []rune(g.Words[g.WordIndex]); position < len(currentWord) {
+			expected = string(currentWord[position])
+		}
+
+		g.CurrentInput = string(input[:position])
+		g.correctError(position)
+		g.recordKey(ActionBackspace, deleted, expected, position, ResultCorrection)
 	}
 }
 
@@ -259,19 +476,23 @@ func (g *Game) WPM() int {
 		return 0
 	}
 
-	correctChars := 0
-	for i, typed := range g.TypedWords {
-		if i < len(g.Words) && typed == g.Words[i] {
-			correctChars += len(typed) + 1
-		}
-	}
-
 	minutes := g.Elapsed.Minutes()
 	if minutes == 0 {
 		return 0
 	}
 
-	return int(float64(correctChars) / 5.0 / minutes)
+	return int(float64(g.correctChars()) / 5.0 / minutes)
+}
+
+// correctChars counts the characters of correctly typed words, each with its space
+func (g *Game) correctChars() int {
+	correctChars := 0
+	for i, typed := range g.TypedWords {
+		if i < len(g.Words) && typed == g.Words[i] {
+			correctChars += utf8.RuneCountInString(typed) + 1
+		}
+	}
+	return correctChars
 }
 
 // RawWPM calculates raw WPM
@@ -308,25 +529,25 @@ func (g *Game) CurrentWordState() (string, string, string) {
 		return "", "", ""
 	}
 
-	word := g.Words[g.WordIndex]
-	input := g.CurrentInput
+	word := []rune(g.Words[g.WordIndex])
+	input := []rune(g.CurrentInput)
 
 	var correct, errors, remaining strings.Builder
 
 	for i := 0; i < len(word); i++ {
 		if i < len(input) {
 			if input[i] == word[i] {
-				correct.WriteByte(word[i])
+				correct.WriteRune(word[i])
 			} else {
-				errors.WriteByte(word[i])
+				errors.WriteRune(word[i])
 			}
 		} else {
-			remaining.WriteByte(word[i])
+			remaining.WriteRune(word[i])
 		}
 	}
 
 	if len(input) > len(word) {
-		errors.WriteString(input[len(word):])
+		errors.WriteString(string(input[len(word):]))
 	}
 
 	return correct.String(), errors.String(), remaining.String()
@@ -1,15 +1,17 @@
 package game
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"ktype/internal/keyboard"
 	"ktype/internal/storage"
 	"ktype/internal/words"
 )
 
 func TestNewTimed(t *testing.T) {
-	game := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	game := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 
 	if game == nil {
 		t.Fatal("NewTimed returned nil")
@@ -33,7 +35,7 @@ func TestNewTimed(t *testing.T) {
 }
 
 func TestNewWords(t *testing.T) {
-	game := NewWords(25, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	game := NewWords(25, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 
 	if game == nil {
 		t.Fatal("NewWords returned nil")
@@ -53,7 +55,7 @@ func TestNewWords(t *testing.T) {
 }
 
 func TestNewZen(t *testing.T) {
-	game := NewZen(words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	game := NewZen(words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 
 	if game == nil {
 		t.Fatal("NewZen returned nil")
@@ -68,6 +70,53 @@ func TestNewZen(t *testing.T) {
 	}
 }
 
+func TestNewQuote(t *testing.T) {
+	game := NewQuote(words.QuoteShort, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+
+	if game.Mode != ModeQuote {
+		t.Errorf("Expected ModeQuote, got %v", game.Mode)
+	}
+
+	if game.Quote == nil {
+		t.Fatal("Expected quote to be set")
+	}
+
+	if strings.Join(game.Words, " ") != game.Quote.Text {
+		t.Errorf("Expected words to spell out the quote, got %q", strings.Join(game.Words, " "))
+	}
+
+	if game.TargetWords != len(game.Words) {
+		t.Errorf("Expected target of %d words, got %d", len(game.Words), game.TargetWords)
+	}
+
+	if game.ModeString() != "quote:short" {
+		t.Errorf("Expected mode string 'quote:short', got %q", game.ModeString())
+	}
+}
+
+func TestQuoteFinishesAtLastWord(t *testing.T) {
+	g := NewQuote(words.QuoteShort, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	q := words.Quote{Text: "Call me Ishmael.", Source: "Herman Melville, Moby-Dick"}
+	g.Quote = &q
+	g.Words = []string{"Call", "me", "Ishmael."}
+	g.TargetWords = 3
+
+	for _, w := range g.Words {
+		for _, r := range w {
+			g.HandleChar(r)
+		}
+		g.HandleSpace()
+	}
+
+	if g.State != StateFinished {
+		t.Error("Expected quote game to finish after the last word")
+	}
+
+	if g.CorrectWordsCount() != 3 {
+		t.Errorf("Expected 3 correct words, got %d", g.CorrectWordsCount())
+	}
+}
+
 func TestModeString(t *testing.T) {
 	tests := []struct {
 		mode     Mode
@@ -85,11 +134,11 @@ func TestModeString(t *testing.T) {
 	for _, tt := range tests {
 		var g *Game
 		if tt.mode == ModeTimed {
-			g = NewTimed(tt.duration, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+			g = NewTimed(tt.duration, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 		} else if tt.mode == ModeWords {
-			g = NewWords(tt.target, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+			g = NewWords(tt.target, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 		} else {
-			g = NewZen(words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+			g = NewZen(words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 		}
 
 		result := g.ModeString()
@@ -100,7 +149,7 @@ func TestModeString(t *testing.T) {
 }
 
 func TestWPM(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 
 	// Test WPM at start (should be 0)
 	if wpm := g.WPM(); wpm != 0 {
@@ -137,7 +186,7 @@ func TestAccuracy(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+			g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 			g.TotalChars = tt.totalChars
 			g.ErrorChars = tt.errorChars
 
@@ -150,7 +199,7 @@ func TestAccuracy(t *testing.T) {
 }
 
 func TestHandleChar(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.Words = []string{"hello", "world"}
 
 	// Type "he" correctly
@@ -178,7 +227,7 @@ func TestHandleChar(t *testing.T) {
 }
 
 func TestHandleSpace(t *testing.T) {
-	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.Words = []string{"hello", "world", "test"}
 	g.CurrentInput = "hello"
 
@@ -198,7 +247,7 @@ func TestHandleSpace(t *testing.T) {
 }
 
 func TestHandleBackspace(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.CurrentInput = "hello"
 
 	g.HandleBackspace()
@@ -214,8 +263,52 @@ func TestHandleBackspace(t *testing.T) {
 	}
 }
 
+func TestHandleCharMultibyte(t *testing.T) {
+	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.Words = []string{"grüße", "żółw"}
+
+	for _, c := range "grüße" {
+		g.HandleChar(c)
+	}
+	if g.ErrorChars != 0 {
+		t.Errorf("Expected no errors typing umlauts, got %d", g.ErrorChars)
+	}
+
+	correct, errors, remaining := g.CurrentWordState()
+	if correct != "grüße" || errors != "" || remaining != "" {
+		t.Errorf("Unexpected word state %q %q %q", correct, errors, remaining)
+	}
+
+	g.HandleSpace()
+	g.HandleChar('z')
+	if g.ErrorChars != 1 {
+		t.Errorf("Expected 'z' for 'ż' to be an error, got %d", g.ErrorChars)
+	}
+	if len(g.Errors) == 0 || g.Errors[len(g.Errors)-1].ExpectedChar != 'ż' {
+		t.Errorf("Expected error to record 'ż' as the expected char, got %+v", g.Errors)
+	}
+
+	_, errors, remaining = g.CurrentWordState()
+	if errors != "ż" || remaining != "ółw" {
+		t.Errorf("Expected errors 'ż' and remaining 'ółw', got %q and %q", errors, remaining)
+	}
+}
+
+func TestHandleBackspaceMultibyte(t *testing.T) {
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.Words = []string{"żółw"}
+	for _, c := range "żó" {
+		g.HandleChar(c)
+	}
+
+	g.HandleBackspace()
+	if g.CurrentInput != "ż" {
+		t.Errorf("Expected backspace to remove a whole rune, got %q", g.CurrentInput)
+	}
+}
+
 func TestTimeRemaining(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.StartTime = time.Now()
 	g.Elapsed = 10 * time.Second
 
@@ -225,14 +318,14 @@ func TestTimeRemaining(t *testing.T) {
 	}
 
 	// Words mode should return -1
-	wordsGame := NewWords(25, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	wordsGame := NewWords(25, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	if wordsGame.TimeRemaining() != -1 {
 		t.Error("Expected -1 for words mode")
 	}
 }
 
 func TestWordsRemaining(t *testing.T) {
-	g := NewWords(10, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewWords(10, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.TypedWords = make([]string, 3)
 
 	remaining := g.WordsRemaining()
@@ -241,14 +334,14 @@ func TestWordsRemaining(t *testing.T) {
 	}
 
 	// Timed mode should return -1
-	timedGame := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	timedGame := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	if timedGame.WordsRemaining() != -1 {
 		t.Error("Expected -1 for timed mode")
 	}
 }
 
 func TestCorrectWordsCount(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.Correct = []bool{true, false, true, true}
 
 	count := g.CorrectWordsCount()
@@ -258,7 +351,7 @@ func TestCorrectWordsCount(t *testing.T) {
 }
 
 func TestCurrentWordState(t *testing.T) {
-	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.Words = []string{"hello"}
 	g.CurrentInput = "he"
 
@@ -278,7 +371,7 @@ func TestCurrentWordState(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	g := NewTimed(1*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap())
+	g := NewTimed(1*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
 	g.StartTime = time.Now().Add(-2 * time.Second) // Started 2s ago
 
 	g.Update()
@@ -291,3 +384,204 @@ func TestUpdate(t *testing.T) {
 		t.Error("Elapsed time should not exceed duration")
 	}
 }
+
+func TestNewFromModeString(t *testing.T) {
+	modes := []string{"time:15", "time:30", "words:10", "words:100", "quote:short", "quote:grind", "zen"}
+	for _, mode := range modes {
+		g, err := NewFromModeString(mode, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+		if err != nil {
+			t.Fatalf("NewFromModeString(%q) returned error: %v", mode, err)
+		}
+		if g.ModeString() != mode {
+			t.Errorf("NewFromModeString(%q).ModeString() = %q", mode, g.ModeString())
+		}
+	}
+
+	invalid := []string{"", "time", "time:abc", "time:0", "words:-5", "time:9999", "sprint:30", "quote:epic"}
+	for _, mode := range invalid {
+		if _, err := NewFromModeString(mode, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir()))); err == nil {
+			t.Errorf("NewFromModeString(%q) should return an error", mode)
+		}
+	}
+}
+
+func TestUseWordList(t *testing.T) {
+	g := NewZen(words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.UseWordList(words.NewListSource("fruits", []string{"apple", "banana"}, words.OrderSequential, words.ComplexityNormal))
+
+	if g.Words[0] != "apple" || g.Words[1] != "banana" || g.Words[2] != "apple" {
+		t.Errorf("Expected words in list order, got %v", g.Words[:3])
+	}
+
+	if g.ModeString() != "zen/list:fruits" {
+		t.Errorf("Expected mode 'zen/list:fruits', got %q", g.ModeString())
+	}
+
+	// Zen refills keep drawing from the list, after the 1000 initial words
+	g.Words = g.Words[:1]
+	g.CurrentInput = "apple"
+	g.HandleSpace()
+	if len(g.Words) != 101 || g.Words[1] != "apple" || g.Words[2] != "banana" {
+		t.Errorf("Expected zen refill to continue the list, got %d words starting %v", len(g.Words), g.Words[:3])
+	}
+}
+
+func TestStrictLetter(t *testing.T) {
+	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.Words = []string{"cat", "dog"}
+	g.Strictness = storage.StrictLetter
+
+	g.HandleChar('c')
+	g.HandleChar('x') // refused
+	if g.CurrentInput != "c" {
+		t.Errorf("Expected the wrong letter to be refused, got %q", g.CurrentInput)
+	}
+	if g.ErrorChars != 1 || g.TotalChars != 2 {
+		t.Errorf("Expected the refused letter to count as an error, got %d/%d", g.ErrorChars, g.TotalChars)
+	}
+	if len(g.CurrentErrors) != 0 {
+		t.Errorf("Expected no live errors for a refused letter, got %v", g.CurrentErrors)
+	}
+
+	g.HandleSpace() // refused, word incomplete
+	if g.WordIndex != 0 {
+		t.Error("Expected space to be refused on an incomplete word")
+	}
+
+	g.HandleChar('a')
+	g.HandleChar('t')
+	g.HandleChar('s') // refused, past the end of the word
+	if g.CurrentInput != "cat" || len(g.CurrentErrors) != 0 {
+		t.Errorf("Expected the extra letter to be refused without a live error, got %q, %v", g.CurrentInput, g.CurrentErrors)
+	}
+	g.HandleSpace()
+	if g.WordIndex != 1 || !g.Correct[0] {
+		t.Errorf("Expected to move on after the correct word, got index %d", g.WordIndex)
+	}
+
+	if g.ModeString() != "words:2/stop:letter" {
+		t.Errorf("Expected mode 'words:2/stop:letter', got %q", g.ModeString())
+	}
+}
+
+func TestStrictWord(t *testing.T) {
+	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.Words = []string{"cat", "dog"}
+	g.Strictness = storage.StrictWord
+
+	for _, c := range "cot" {
+		g.HandleChar(c)
+	}
+	if g.CurrentInput != "cot" {
+		t.Errorf("Expected letters to be accepted in word mode, got %q", g.CurrentInput)
+	}
+
+	g.HandleSpace()
+	if g.WordIndex != 0 {
+		t.Error("Expected space to be refused while the word is wrong")
+	}
+
+	g.HandleBackspace()
+	g.HandleBackspace()
+	g.HandleChar('a')
+	g.HandleChar('t')
+	g.HandleSpace()
+	if g.WordIndex != 1 {
+		t.Error("Expected to move on once the word matches")
+	}
+}
+
+func TestModeOption(t *testing.T) {
+	mode := "time:30/list:german/stop:word"
+	if v := modeOption(mode, "stop"); v != "word" {
+		t.Errorf("Expected stop 'word', got %q", v)
+	}
+	if v := modeOption(mode, "list"); v != "german" {
+		t.Errorf("Expected list 'german', got %q", v)
+	}
+	if v := modeOption("time:30", "stop"); v != "" {
+		t.Errorf("Expected no stop option, got %q", v)
+	}
+}
+
+func TestScore(t *testing.T) {
+	g := NewWords(2, words.DifficultyHard, words.ComplexityPunctuation, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	g.Words = []string{"ok", "no"}
+	for _, r := range "ok" {
+		g.HandleChar(r)
+	}
+	g.HandleSpace()
+	for _, r := range "nox" {
+		g.HandleChar(r)
+	}
+	g.HandleSpace()
+	g.Elapsed = 6 * time.Second
+
+	score := g.Score()
+	if score.Mode != "words:2" || score.Difficulty != "hard" || score.Complexity != "punctuation" {
+		t.Errorf("Expected the test's settings in the score, got %+v", score)
+	}
+	if score.Duration != 6*time.Second {
+		t.Errorf("Expected duration 6s, got %v", score.Duration)
+	}
+	if score.CorrectChars != 3 || score.ExtraChars != 1 || score.IncorrectChars != 0 || score.MissedChars != 0 {
+		t.Errorf("Unexpected character counts %+v", score)
+	}
+	if score.WPM != g.WPM() || score.RawWPM != g.RawWPM() || score.Accuracy != g.Accuracy() {
+		t.Errorf("Expected the game's speed and accuracy, got %+v", score)
+	}
+
+	// Word lists are recorded by name instead of difficulty
+	g.UseWordList(words.NewListSource("fruit", []string{"apple"}, words.OrderRandom, words.ComplexityNormal))
+	score = g.Score()
+	if score.WordList != "fruit" || score.Difficulty != "" {
+		t.Errorf("Expected the word list instead of a difficulty, got %+v", score)
+	}
+}
+
+func TestEmulation(t *testing.T) {
+	hm := storage.NewHeatmap(storage.NewRoot(t.TempDir()))
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, hm)
+	g.Words = []string{"rst"}
+	g.Emulation = keyboard.NewEmulation(keyboard.Colemak)
+
+	// The QWERTY keys s, d and f type r, s and t on Colemak
+	for _, r := range "sdf" {
+		g.HandleChar(g.Emulation.Translate(r))
+	}
+	if g.CurrentInput != "rst" || g.ErrorChars != 0 {
+		t.Errorf("Expected rst typed without errors, got %q with %d errors", g.CurrentInput, g.ErrorChars)
+	}
+	if stat := hm.Keys["r"]; stat == nil || stat.TotalHits != 1 {
+		t.Errorf("Expected the heatmap to record the emulated key r, got %+v", stat)
+	}
+	if stat := hm.Keys["d"]; stat != nil {
+		t.Errorf("Expected no hits for the physical key d, got %+v", stat)
+	}
+
+	if g.ModeString() != "words:1/emulate:colemak" {
+		t.Errorf("Expected mode 'words:1/emulate:colemak', got %q", g.ModeString())
+	}
+}
+
+func TestHandleCharRecordsLatency(t *testing.T) {
+	hm := storage.NewHeatmap(storage.NewRoot(t.TempDir()))
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, hm)
+	g.Words = []string{"ab"}
+
+	g.HandleChar('a')
+	g.HandleChar('b')
+	if stat := hm.Keys["a"]; stat == nil || stat.Timed != 0 {
+		t.Errorf("Expected the first key to be untimed, got %+v", stat)
+	}
+	if stat := hm.Keys["b"]; stat == nil || stat.Timed != 1 {
+		t.Errorf("Expected the second key to be timed, got %+v", stat)
+	}
+
+	// A long pause isn't timed
+	g.Keystrokes[len(g.Keystrokes)-1].Time = time.Now().Add(-time.Minute)
+	g.HandleChar('x')
+	if stat := hm.Keys["x"]; stat == nil || stat.Timed != 0 {
+		t.Errorf("Expected the key after a pause to be untimed, got %+v", stat)
+	}
+}
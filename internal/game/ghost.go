@@ -0,0 +1,101 @@
+package game
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Ghost is a pacer raced against while typing. It either moves at a fixed
+// WPM or replays the keystroke timeline of a recorded run.
+type Ghost struct {
+	WPM    int     // Fixed pace, used when there is no recorded run
+	Log    *KeyLog // Recorded run to replay
+	Label  string  // Shown to the user, e.g. "pb" or "60 wpm"
+	replay *Replay
+}
+
+// NewPaceGhost creates a ghost that types at a constant speed
+func NewPaceGhost(wpm int) *Ghost {
+	return &Ghost{
+		WPM:   wpm,
+		Label: fmt.Sprintf("%d wpm", wpm),
+	}
+}
+
+// NewReplayGhost creates a ghost that replays a recorded run
+func NewReplayGhost(log *KeyLog, label string) *Ghost {
+	return &Ghost{
+		Log:    log,
+		Label:  label,
+		replay: NewReplay(log),
+	}
+}
+
+// Progress returns how many correct characters (including spaces after
+// correct words) the ghost has typed after elapsed time
+func (gh *Ghost) Progress(elapsed time.Duration) int {
+	if gh.replay == nil {
+		return int(float64(gh.WPM) * 5 * elapsed.Minutes())
+	}
+
+	gh.replay.Seek(elapsed)
+	return gh.replay.Game.ProgressChars()
+}
+
+// ProgressChars returns the number of correct characters typed so far,
+// counted the same way as WPM (a correct word plus its space)
+func (g *Game) ProgressChars() int {
+	chars := 0
+	for i, typed := range g.TypedWords {
+		if i < len(g.Words) && typed == g.Words[i] {
+			chars += utf8.RuneCountInString(typed) + 1
+		}
+	}
+
+	if g.WordIndex < len(g.Words) {
+		word := []rune(g.Words[g.WordIndex])
+		input := []rune(g.CurrentInput)
+		for i := 0; i < len(input) && i < len(word); i++ {
+			if input[i] != word[i] {
+				break
+			}
+			chars++
+		}
+	}
+
+	return chars
+}
+
+// GhostPosition returns the word and character (rune index) the ghost is currently at
+func (g *Game) GhostPosition() (wordIndex, charIndex int, ok bool) {
+	if g.Ghost == nil || g.StartTime.IsZero() {
+		return 0, 0, false
+	}
+
+	remaining := g.Ghost.Progress(g.Elapsed)
+	for i, word := range g.Words {
+		wordLen := utf8.RuneCountInString(word)
+		if remaining < wordLen {
+			return i, remaining, true
+		}
+		remaining -= wordLen + 1
+		if remaining < 0 {
+			return i + 1, 0, i+1 < len(g.Words)
+		}
+	}
+
+	return 0, 0, false
+}
+
+// GhostLead returns how many characters the player is ahead of the ghost
+// (negative when behind) and the ghost's WPM at the current time
+func (g *Game) GhostLead() (lead int, ghostWPM int) {
+	if g.Ghost == nil || g.Elapsed == 0 {
+		return 0, 0
+	}
+
+	ghostChars := g.Ghost.Progress(g.Elapsed)
+	ghostWPM = int(float64(ghostChars) / 5.0 / g.Elapsed.Minutes())
+	return g.ProgressChars() - ghostChars, ghostWPM
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaceGhostProgress(t *testing.T) {
+	gh := NewPaceGhost(60)
+
+	// 60 wpm = 300 chars per minute = 5 chars per second
+	if p := gh.Progress(2 * time.Second); p != 10 {
+		t.Errorf("Expected 10 chars after 2s at 60 wpm, got %d", p)
+	}
+
+	if gh.Label != "60 wpm" {
+		t.Errorf("Expected label '60 wpm', got %q", gh.Label)
+	}
+}
+
+func TestReplayGhostProgress(t *testing.T) {
+	gh := NewReplayGhost(testKeyLog(), "pb")
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{0, 0},
+		{100 * time.Millisecond, 1}, // "a"
+		{200 * time.Millisecond, 1}, // "ax" - only the correct prefix counts
+		{400 * time.Millisecond, 2}, // "ab"
+		{500 * time.Millisecond, 3}, // "ab " submitted
+		{700 * time.Millisecond, 5}, // "ab cd"
+		{10 * time.Second, 5},       // stays at the end of the recording
+		{300 * time.Millisecond, 1}, // can go back in time
+	}
+
+	for _, tt := range tests {
+		if p := gh.Progress(tt.elapsed); p != tt.expected {
+			t.Errorf("Progress(%v) = %d, expected %d", tt.elapsed, p, tt.expected)
+		}
+	}
+}
+
+func TestGhostPosition(t *testing.T) {
+	g := NewWords(3, 0, 0, nil)
+	g.Words = []string{"abc", "de", "fgh"}
+	g.Ghost = NewPaceGhost(60)
+	g.StartTime = time.Now()
+
+	tests := []struct {
+		elapsed   time.Duration
+		wordIndex int
+		charIndex int
+	}{
+		{0, 0, 0},
+		{400 * time.Millisecond, 0, 2}, // 2 chars
+		{600 * time.Millisecond, 1, 0}, // 3 chars: on the space, shown at next word
+		{800 * time.Millisecond, 1, 0}, // 4 chars
+		{1000 * time.Millisecond, 1, 1},
+		{1400 * time.Millisecond, 2, 0},
+	}
+
+	for _, tt := range tests {
+		g.Elapsed = tt.elapsed
+		w, c, ok := g.GhostPosition()
+		if !ok || w != tt.wordIndex || c != tt.charIndex {
+			t.Errorf("At %v: got (%d, %d, %v), expected (%d, %d)", tt.elapsed, w, c, ok, tt.wordIndex, tt.charIndex)
+		}
+	}
+
+	g.Elapsed = time.Minute
+	if _, _, ok := g.GhostPosition(); ok {
+		t.Error("Expected no ghost position once the ghost is past the last word")
+	}
+}
+
+func TestGhostLead(t *testing.T) {
+	g := NewWords(2, 0, 0, nil)
+	g.Words = []string{"hello", "world"}
+	g.Ghost = NewPaceGhost(60)
+
+	for _, c := range "hello" {
+		g.HandleChar(c)
+	}
+	g.HandleSpace()
+	g.Elapsed = time.Second // ghost typed 5 chars, player 6
+
+	lead, ghostWPM := g.GhostLead()
+	if lead != 1 {
+		t.Errorf("Expected lead of 1 char, got %d", lead)
+	}
+	if ghostWPM != 60 {
+		t.Errorf("Expected ghost at 60 wpm, got %d", ghostWPM)
+	}
+
+	g.Elapsed = 2 * time.Second
+	if lead, _ := g.GhostLead(); lead != -4 {
+		t.Errorf("Expected to be 4 chars behind, got %d", lead)
+	}
+}
+
+func TestProgressChars(t *testing.T) {
+	g := NewWords(3, 0, 0, nil)
+	g.Words = []string{"one", "two", "three"}
+	g.TypedWords = []string{"one", "twx"}
+	g.WordIndex = 2
+	g.CurrentInput = "thx"
+
+	// "one " (4) + wrong word (0) + "th" (2)
+	if p := g.ProgressChars(); p != 6 {
+		t.Errorf("Expected 6 progress chars, got %d", p)
+	}
+}
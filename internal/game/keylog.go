@@ -0,0 +1,192 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"ktype/internal/storage"
+)
+
+// KeyAction is the kind of key that produced a keystroke event
+type KeyAction int
+
+const (
+	ActionChar KeyAction = iota
+	ActionSpace
+	ActionBackspace
+)
+
+// String returns a string representation of the action
+func (a KeyAction) String() string {
+	switch a {
+	case ActionSpace:
+		return "space"
+	case ActionBackspace:
+		return "backspace"
+	default:
+		return "char"
+	}
+}
+
+// MarshalText encodes the action by name
+func (a KeyAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes an action name
+func (a *KeyAction) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "char":
+		*a = ActionChar
+	case "space":
+		*a = ActionSpace
+	case "backspace":
+		*a = ActionBackspace
+	default:
+		return fmt.Errorf("unknown key action %q", text)
+	}
+	return nil
+}
+
+// KeyResult describes how a keystroke was judged
+type KeyResult int
+
+const (
+	ResultCorrect    KeyResult = iota // Typed the expected character / submitted a correct word
+	ResultWrong                       // Typed a different character / submitted a wrong word
+	ResultExtra                       // Typed past the end of the word
+	ResultCorrection                  // Deleted a character with backspace
+)
+
+// String returns a string representation of the result
+func (r KeyResult) String() string {
+	switch r {
+	case ResultWrong:
+		return "wrong"
+	case ResultExtra:
+		return "extra"
+	case ResultCorrection:
+		return "correction"
+	default:
+		return "correct"
+	}
+}
+
+// MarshalText encodes the result by name
+func (r KeyResult) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a result name
+func (r *KeyResult) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "correct":
+		*r = ResultCorrect
+	case "wrong":
+		*r = ResultWrong
+	case "extra":
+		*r = ResultExtra
+	case "correction":
+		*r = ResultCorrection
+	default:
+		return fmt.Errorf("unknown key result %q", text)
+	}
+	return nil
+}
+
+// KeyEvent is a single timestamped keystroke
+type KeyEvent struct {
+	Time      time.Time     `json:"time"`
+	Offset    time.Duration `json:"offset"` // Time since the test started
+	Action    KeyAction     `json:"action"`
+	Key       string        `json:"key,omitempty"`      // Typed (or deleted) character
+	Expected  string        `json:"expected,omitempty"` // Character the word called for at this position
+	WordIndex int           `json:"word_index"`
+	Position  int           `json:"position"` // Character position within the word
+	Result    KeyResult     `json:"result"`
+}
+
+// KeyLog is the complete keystroke record of a test
+type KeyLog struct {
+	Version     int           `json:"version"`
+	Mode        string        `json:"mode"`
+	StartTime   time.Time     `json:"start_time"`
+	Elapsed     time.Duration `json:"elapsed"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	TargetWords int           `json:"target_words,omitempty"`
+	Words       []string      `json:"words"`
+	Events      []KeyEvent    `json:"events"`
+}
+
+// recordKey appends a keystroke to the game's event log
+func (g *Game) recordKey(action KeyAction, key, expected string, position int, result KeyResult) {
+	now := g.clock()
+	g.Keystrokes = append(g.Keystrokes, KeyEvent{
+		Time:      now,
+		Offset:    now.Sub(g.StartTime),
+		Action:    action,
+		Key:       key,
+		Expected:  expected,
+		WordIndex: g.WordIndex,
+		Position:  position,
+		Result:    result,
+	})
+}
+
+// keyLatency returns the time since the previous keystroke, or zero if there
+// was none or the typist paused for longer than maxNGramGap, which would say
+// nothing about how hard the next key is to reach
+func (g *Game) keyLatency() time.Duration {
+	if len(g.Keystrokes) == 0 {
+		return 0
+	}
+	gap := time.Since(g.Keystrokes[len(g.Keystrokes)-1].Time)
+	if gap > maxNGramGap {
+		return 0
+	}
+	return gap
+}
+
+// KeyLog returns the keystroke record of the game
+func (g *Game) KeyLog() *KeyLog {
+	return &KeyLog{
+		Mode:        g.ModeString(),
+		StartTime:   g.StartTime,
+		Elapsed:     g.Elapsed,
+		Duration:    g.Duration,
+		TargetWords: g.TargetWords,
+		Words:       g.Words,
+		Events:      g.Keystrokes,
+	}
+}
+
+// Save writes the key log to a JSON file
+func (l *KeyLog) Save(path string) error {
+	l.Version = storage.KeyLogVersion()
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteKeyLog(path, data)
+}
+
+// LoadKeyLog reads a key log from a JSON file
+func LoadKeyLog(path string) (*KeyLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var l KeyLog
+	data, err = storage.UpgradeKeyLog(data)
+	if err == nil {
+		err = json.Unmarshal(data, &l)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid key log %s: %w", path, err)
+	}
+	return &l, nil
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ktype/internal/storage"
+	"ktype/internal/words"
+)
+
+func TestKeystrokesRecorded(t *testing.T) {
+	g := NewWords(2, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	g.Words = []string{"hi", "yo", "extra"}
+
+	g.HandleChar('h')
+	g.HandleChar('x') // wrong
+	g.HandleBackspace()
+	g.HandleChar('i')
+	g.HandleChar('!') // extra
+	g.HandleSpace()
+
+	expected := []struct {
+		action   KeyAction
+		key      string
+		expected string
+		position int
+		result   KeyResult
+	}{
+		{ActionChar, "h", "h", 0, ResultCorrect},
+		{ActionChar, "x", "i", 1, ResultWrong},
+		{ActionBackspace, "x", "i", 1, ResultCorrection},
+		{ActionChar, "i", "i", 1, ResultCorrect},
+		{ActionChar, "!", "", 2, ResultExtra},
+		{ActionSpace, " ", " ", 3, ResultWrong},
+	}
+
+	if len(g.Keystrokes) != len(expected) {
+		t.Fatalf("Expected %d keystrokes, got %d", len(expected), len(g.Keystrokes))
+	}
+
+	for i, e := range expected {
+		got := g.Keystrokes[i]
+		if got.Action != e.action || got.Key != e.key || got.Expected != e.expected || got.Position != e.position || got.Result != e.result {
+			t.Errorf("Keystroke %d = %+v, expected %+v", i, got, e)
+		}
+		if got.WordIndex != 0 {
+			t.Errorf("Keystroke %d: expected word index 0, got %d", i, got.WordIndex)
+		}
+		if got.Time.IsZero() {
+			t.Errorf("Keystroke %d has no timestamp", i)
+		}
+		if i > 0 && got.Offset < g.Keystrokes[i-1].Offset {
+			t.Errorf("Keystroke %d offset went backwards", i)
+		}
+	}
+}
+
+func TestBackspaceOnEmptyInputNotRecorded(t *testing.T) {
+	g := NewTimed(30*time.Second, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	g.HandleBackspace()
+
+	if len(g.Keystrokes) != 0 {
+		t.Errorf("Expected no keystrokes, got %d", len(g.Keystrokes))
+	}
+}
+
+func TestKeyLogSaveAndLoad(t *testing.T) {
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	g.Words = []string{"ok", "next"}
+	g.HandleChar('o')
+	g.HandleChar('k')
+	g.HandleSpace()
+
+	path := filepath.Join(t.TempDir(), "log.json")
+	if err := g.KeyLog().Save(path); err != nil {
+		t.Fatalf("Failed to save key log: %v", err)
+	}
+
+	loaded, err := LoadKeyLog(path)
+	if err != nil {
+		t.Fatalf("Failed to load key log: %v", err)
+	}
+
+	if loaded.Mode != "words:1" {
+		t.Errorf("Expected mode 'words:1', got %q", loaded.Mode)
+	}
+
+	if len(loaded.Events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(loaded.Events))
+	}
+
+	if loaded.Events[2].Action != ActionSpace || loaded.Events[2].Result != ResultCorrect {
+		t.Errorf("Unexpected last event: %+v", loaded.Events[2])
+	}
+
+	if len(loaded.Words) != 2 || loaded.Words[0] != "ok" {
+		t.Errorf("Expected words to round-trip, got %v", loaded.Words)
+	}
+}
+
+func TestKeyLogSaveCreatesDirectory(t *testing.T) {
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, storage.NewHeatmap(storage.NewRoot(t.TempDir())))
+	g.Words = []string{"ok"}
+	g.HandleChar('o')
+
+	dir := filepath.Join(t.TempDir(), "keylogs")
+	if err := g.KeyLog().Save(filepath.Join(dir, "log.json")); err != nil {
+		t.Fatalf("Failed to save key log: %v", err)
+	}
+
+	// Written through a temporary file, which must not be left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "log.json" {
+		t.Errorf("Expected only log.json in the directory, got %v (%v)", entries, err)
+	}
+}
+
+func TestLoadKeyLogInvalid(t *testing.T) {
+	if _, err := LoadKeyLog(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing key log")
+	}
+}
+
+func TestLoadKeyLogUnversioned(t *testing.T) {
+	// Logs saved before files were versioned still load
+	loaded, err := LoadKeyLog(filepath.Join("testdata", "keylog-v0.json"))
+	if err != nil {
+		t.Fatalf("Failed to load unversioned key log: %v", err)
+	}
+
+	if loaded.Version != storage.KeyLogVersion() {
+		t.Errorf("Expected version %d after load, got %d", storage.KeyLogVersion(), loaded.Version)
+	}
+	if loaded.Mode != "words:1" || len(loaded.Events) != 3 || loaded.Events[2].Action != ActionSpace {
+		t.Errorf("Unexpected key log %+v", loaded)
+	}
+}
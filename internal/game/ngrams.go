@@ -0,0 +1,61 @@
+package game
+
+import (
+	"time"
+
+	"ktype/internal/storage"
+)
+
+// NGramSizes are the lengths of the character sequences whose timing is tracked
+var NGramSizes = []int{2, 3}
+
+// maxNGramGap is the longest pause between two keys of an n-gram. Longer
+// pauses mean the typist stopped to think or look away, which says nothing
+// about how hard the sequence is to type.
+const maxNGramGap = 2 * time.Second
+
+// NGrams returns a sample for every bigram and trigram typed in the log.
+// N-grams are taken from runs of consecutive characters within a word, named
+// by the characters the text called for, and timed from their first key to
+// their last. Backspaces and strict-mode retries break a run, as do extra
+// characters past the end of a word.
+func (l *KeyLog) NGrams() []storage.NGramSample {
+	var samples []storage.NGramSample
+	var run []KeyEvent
+
+	for _, e := range l.Events {
+		continues := len(run) > 0 &&
+			e.Action == ActionChar && e.Expected != "" &&
+			e.WordIndex == run[len(run)-1].WordIndex &&
+			e.Position == run[len(run)-1].Position+1 &&
+			e.Time.Sub(run[len(run)-1].Time) <= maxNGramGap
+
+		switch {
+		case continues:
+			run = append(run, e)
+		case e.Action == ActionChar && e.Expected != "":
+			run = append(run[:0], e)
+			continue
+		default:
+			run = run[:0]
+			continue
+		}
+
+		for _, n := range NGramSizes {
+			if len(run) < n {
+				continue
+			}
+			keys := run[len(run)-n:]
+			sample := storage.NGramSample{Latency: keys[n-1].Time.Sub(keys[0].Time)}
+			for _, k := range keys {
+				sample.NGram += k.Expected
+				if k.Result != ResultCorrect {
+					sample.Error = true
+				}
+			}
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"ktype/internal/words"
+)
+
+// typed builds a key log of correctly typed words, one key every gap
+func typed(gap time.Duration, words ...string) *KeyLog {
+	start := time.Now()
+	l := &KeyLog{Words: words}
+	at := start
+	for i, word := range words {
+		for pos, r := range word {
+			l.Events = append(l.Events, KeyEvent{Time: at, Action: ActionChar, Key: string(r), Expected: string(r), WordIndex: i, Position: pos, Result: ResultCorrect})
+			at = at.Add(gap)
+		}
+		l.Events = append(l.Events, KeyEvent{Time: at, Action: ActionSpace, Key: " ", Expected: " ", WordIndex: i, Position: len(word), Result: ResultCorrect})
+		at = at.Add(gap)
+	}
+	return l
+}
+
+func TestKeyLogNGrams(t *testing.T) {
+	samples := typed(100*time.Millisecond, "the", "ox").NGrams()
+
+	want := []struct {
+		ngram   string
+		latency time.Duration
+	}{
+		{"th", 100 * time.Millisecond},
+		{"he", 100 * time.Millisecond},
+		{"the", 200 * time.Millisecond},
+		{"ox", 100 * time.Millisecond},
+	}
+	if len(samples) != len(want) {
+		t.Fatalf("Expected %d samples, got %d: %+v", len(want), len(samples), samples)
+	}
+	for i, w := range want {
+		if samples[i].NGram != w.ngram || samples[i].Latency != w.latency || samples[i].Error {
+			t.Errorf("Sample %d = %+v, want %s in %v", i, samples[i], w.ngram, w.latency)
+		}
+	}
+}
+
+func TestKeyLogNGramsErrors(t *testing.T) {
+	l := typed(100*time.Millisecond, "cat")
+	l.Events[1].Key = "x"
+	l.Events[1].Result = ResultWrong
+
+	for _, s := range l.NGrams() {
+		if !s.Error {
+			t.Errorf("%s should count as an error: it includes the mistyped a", s.NGram)
+		}
+	}
+}
+
+func TestKeyLogNGramsBreaks(t *testing.T) {
+	g := NewWords(1, words.DifficultyMedium, words.ComplexityNormal, nil)
+	g.Words = []string{"abcd"}
+	g.HandleChar('a')
+	g.HandleChar('b')
+	g.HandleBackspace()
+	g.HandleChar('b')
+	g.HandleChar('c')
+
+	// The backspace breaks the run, so no trigram spans it
+	got := map[string]int{}
+	for _, s := range g.KeyLog().NGrams() {
+		got[s.NGram]++
+	}
+	if got["ab"] != 1 || got["bc"] != 1 || got["abc"] != 0 {
+		t.Errorf("Unexpected n-grams after a correction: %v", got)
+	}
+
+	// A long pause breaks the run too
+	l := typed(3*time.Second, "slow")
+	if samples := l.NGrams(); len(samples) != 0 {
+		t.Errorf("Expected no samples across long pauses, got %+v", samples)
+	}
+}
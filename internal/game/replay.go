@@ -0,0 +1,162 @@
+package game
+
+import (
+	"strings"
+	"time"
+
+	"ktype/internal/storage"
+)
+
+// Replay speed limits
+const (
+	MinReplaySpeed = 0.25
+	MaxReplaySpeed = 4.0
+)
+
+// Replay plays a key log back through a fresh game so it can be rendered at any point in time
+type Replay struct {
+	Log      *KeyLog
+	Game     *Game
+	Position time.Duration // Current playback position
+	Speed    float64
+	Paused   bool
+
+	next     int // Index of the next event to apply
+	lastTick time.Time
+}
+
+// NewReplay creates a replay positioned at the start of the log
+func NewReplay(log *KeyLog) *Replay {
+	r := &Replay{
+		Log:   log,
+		Speed: 1.0,
+	}
+	r.reset()
+	return r
+}
+
+// reset rebuilds the game from scratch at position zero
+func (r *Replay) reset() {
+	mode, _, _ := strings.Cut(r.Log.Mode, ":")
+
+	g := &Game{
+		Words:         append([]string(nil), r.Log.Words...),
+		Correct:       make([]bool, 0),
+		TypedWords:    make([]string, 0),
+		Duration:      r.Log.Duration,
+		TargetWords:   r.Log.TargetWords,
+		State:         StatePlaying,
+		StartTime:     r.Log.StartTime,
+		Errors:        make([]TypingError, 0),
+		CurrentErrors: make([]int, 0),
+		Keystrokes:    make([]KeyEvent, 0),
+	}
+
+	// An unknown stop mode would replay as off, which is the best that can be done
+	g.Strictness, _ = storage.ParseStrictness(modeOption(r.Log.Mode, "stop"))
+
+	switch mode {
+	case "time":
+		g.Mode = ModeTimed
+	case "words":
+		g.Mode = ModeWords
+	case "quote":
+		g.Mode = ModeQuote
+	default:
+		g.Mode = ModeZen
+	}
+
+	// Keystrokes happen when they did in the recording
+	g.now = func() time.Time {
+		return r.Log.StartTime.Add(r.Log.Events[r.next].Offset)
+	}
+
+	r.Game = g
+	r.Position = 0
+	r.next = 0
+}
+
+// Total returns the length of the recording
+func (r *Replay) Total() time.Duration {
+	total := r.Log.Elapsed
+	if n := len(r.Log.Events); n > 0 && r.Log.Events[n-1].Offset > total {
+		total = r.Log.Events[n-1].Offset
+	}
+	return total
+}
+
+// Finished returns true once playback has reached the end of the recording
+func (r *Replay) Finished() bool {
+	return r.Position >= r.Total()
+}
+
+// Advance moves playback forward by the wall-clock time since the last call, scaled by Speed
+func (r *Replay) Advance(now time.Time) {
+	if r.lastTick.IsZero() || r.Paused {
+		r.lastTick = now
+		return
+	}
+
+	step := time.Duration(float64(now.Sub(r.lastTick)) * r.Speed)
+	r.lastTick = now
+	r.Seek(r.Position + step)
+
+	if r.Finished() {
+		r.Paused = true
+	}
+}
+
+// Seek moves playback to the given position, replaying events from the start when going backwards
+func (r *Replay) Seek(pos time.Duration) {
+	if pos < 0 {
+		pos = 0
+	}
+	if total := r.Total(); pos > total {
+		pos = total
+	}
+
+	if pos < r.Position {
+		r.reset()
+	}
+
+	for r.next < len(r.Log.Events) && r.Log.Events[r.next].Offset <= pos {
+		r.apply(r.Log.Events[r.next])
+		r.next++
+	}
+
+	r.Position = pos
+	r.Game.Elapsed = pos
+}
+
+// TogglePause pauses or resumes playback, restarting from the beginning if it already ended
+func (r *Replay) TogglePause() {
+	if r.Paused && r.Finished() {
+		r.Seek(0)
+	}
+	r.Paused = !r.Paused
+}
+
+// SetSpeed changes the playback speed, clamped to the supported range
+func (r *Replay) SetSpeed(speed float64) {
+	if speed < MinReplaySpeed {
+		speed = MinReplaySpeed
+	}
+	if speed > MaxReplaySpeed {
+		speed = MaxReplaySpeed
+	}
+	r.Speed = speed
+}
+
+// apply feeds a recorded keystroke into the replay game
+func (r *Replay) apply(e KeyEvent) {
+	switch e.Action {
+	case ActionChar:
+		for _, c := range e.Key {
+			r.Game.HandleChar(c)
+		}
+	case ActionSpace:
+		r.Game.HandleSpace()
+	case ActionBackspace:
+		r.Game.HandleBackspace()
+	}
+}
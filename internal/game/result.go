@@ -0,0 +1,27 @@
+package game
+
+import "ktype/internal/storage"
+
+// Score returns the full result of the game as it is stored in the leaderboard
+func (g *Game) Score() storage.Score {
+	errors := g.ErrorsByType()
+	score := storage.Score{
+		WPM:            g.WPM(),
+		RawWPM:         g.RawWPM(),
+		Accuracy:       g.Accuracy(),
+		Consistency:    g.Consistency(),
+		Mode:           g.ModeString(),
+		Duration:       g.Elapsed,
+		CorrectChars:   g.correctChars(),
+		IncorrectChars: errors[ErrorWrongChar] + errors[ErrorTransposition],
+		ExtraChars:     errors[ErrorExtraChar],
+		MissedChars:    errors[ErrorMissingChar],
+	}
+
+	filter := g.PBFilter()
+	score.Difficulty = filter.Difficulty
+	score.Complexity = filter.Complexity
+	score.WordList = filter.WordList
+
+	return score
+}
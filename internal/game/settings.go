@@ -0,0 +1,61 @@
+package game
+
+import (
+	"strings"
+
+	"ktype/internal/storage"
+	"ktype/internal/words"
+)
+
+// Settings are the parts of a test's setup that scores record and personal
+// bests are kept apart by, besides the mode itself
+type Settings struct {
+	Difficulty words.Difficulty
+	Complexity words.Complexity
+	WordList   string // Custom word list, or "" for the built-in words
+	Adaptive   bool
+	Strictness storage.Strictness
+	Emulated   string // Layout typed through emulation, or ""
+}
+
+// ModeString returns the mode string of a test of the given mode, such as
+// "time:30" or "quote:short", played with these settings. Quotes and adaptive
+// practice never use a word list.
+func (s Settings) ModeString(mode string) string {
+	if s.WordList != "" && !s.Adaptive && !isQuote(mode) {
+		mode += "/list:" + s.WordList
+	}
+	if s.Adaptive {
+		mode += "/adaptive"
+	}
+	if s.Strictness != storage.StrictOff {
+		mode += "/stop:" + s.Strictness.String()
+	}
+	if s.Emulated != "" {
+		mode += "/emulate:" + s.Emulated
+	}
+	return mode
+}
+
+// PBFilter returns the filter that finds the personal best for a test of the
+// given mode played with these settings. It matches what Game.Score records:
+// quotes have neither difficulty nor complexity, and word lists bring their
+// own words in place of a difficulty.
+func (s Settings) PBFilter(mode string) storage.ScoreFilter {
+	filter := storage.ScoreFilter{Mode: s.ModeString(mode)}
+	if isQuote(mode) {
+		return filter
+	}
+	filter.Complexity = s.Complexity.String()
+	if s.WordList != "" && !s.Adaptive {
+		filter.WordList = s.WordList
+	} else {
+		filter.Difficulty = s.Difficulty.String()
+	}
+	return filter
+}
+
+// isQuote reports whether mode is a quote mode
+func isQuote(mode string) bool {
+	return strings.HasPrefix(mode, "quote")
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"ktype/internal/storage"
+	"ktype/internal/words"
+)
+
+func TestPBFilter(t *testing.T) {
+	hm := storage.NewHeatmap(storage.NewRoot(t.TempDir()))
+
+	g := NewTimed(30*time.Second, words.DifficultyHard, words.ComplexityFull, hm)
+	g.Strictness = storage.StrictWord
+	expected := storage.ScoreFilter{Mode: "time:30/stop:word", Difficulty: "hard", Complexity: "full"}
+	if got := g.PBFilter(); got != expected {
+		t.Errorf("PBFilter() = %+v, expected %+v", got, expected)
+	}
+
+	// The filter matches the score the game records
+	if !g.PBFilter().Matches(g.Score()) {
+		t.Error("Expected the game's own score to match its PB filter")
+	}
+
+	g = NewWords(25, words.DifficultyEasy, words.ComplexityNormal, hm)
+	g.UseWordList(words.NewListSource("fruits", []string{"apple"}, words.OrderRandom, words.ComplexityNormal))
+	expected = storage.ScoreFilter{Mode: "words:25/list:fruits", Complexity: "normal", WordList: "fruits"}
+	if got := g.PBFilter(); got != expected {
+		t.Errorf("PBFilter() = %+v, expected %+v", got, expected)
+	}
+
+	g = NewQuote(words.QuoteShort, hm)
+	if got := g.PBFilter(); got != (storage.ScoreFilter{Mode: "quote:short"}) {
+		t.Errorf("Expected quotes to be filtered by mode only, got %+v", got)
+	}
+}
+
+func TestSettingsPBFilter(t *testing.T) {
+	// The menu looks PBs up from the session's settings before any game exists
+	settings := Settings{
+		Difficulty: words.DifficultyMedium,
+		Complexity: words.ComplexityPunctuation,
+		WordList:   "german",
+		Emulated:   "colemak",
+	}
+
+	tests := []struct {
+		mode     string
+		expected storage.ScoreFilter
+	}{
+		{"time:30", storage.ScoreFilter{Mode: "time:30/list:german/emulate:colemak", Complexity: "punctuation", WordList: "german"}},
+		{"quote:long", storage.ScoreFilter{Mode: "quote:long/emulate:colemak"}},
+	}
+
+	for _, tt := range tests {
+		if got := settings.PBFilter(tt.mode); got != tt.expected {
+			t.Errorf("PBFilter(%q) = %+v, expected %+v", tt.mode, got, tt.expected)
+		}
+	}
+
+	// Adaptive practice draws from the built-in words, never the list
+	settings.Adaptive = true
+	if got := settings.PBFilter("words:50"); got.Mode != "words:50/adaptive/emulate:colemak" || got.Difficulty != "medium" || got.WordList != "" {
+		t.Errorf("Unexpected adaptive filter %+v", got)
+	}
+}
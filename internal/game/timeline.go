@@ -0,0 +1,84 @@
+package game
+
+import (
+	"math"
+	"time"
+)
+
+// Sample is a snapshot of a test taken once per second
+type Sample struct {
+	Second int     `json:"second"`  // End of the sampled interval, in seconds since the start
+	WPM    float64 `json:"wpm"`     // WPM of the test so far
+	RawWPM float64 `json:"raw_wpm"` // Raw WPM typed within this interval
+	Errors int     `json:"errors"`  // Errors made within this interval
+}
+
+// recordSamples appends a sample for every full second elapsed since the last one
+func (g *Game) recordSamples() {
+	for time.Duration(len(g.Timeline)+1)*time.Second <= g.Elapsed {
+		g.addSample(time.Second)
+	}
+}
+
+// addSample records the next interval of the timeline, normally one second long
+func (g *Game) addSample(interval time.Duration) {
+	end := time.Duration(len(g.Timeline))*time.Second + interval
+	chars := g.TotalChars - g.sampledChars
+	errors := g.ErrorChars - g.sampledErrors
+
+	g.Timeline = append(g.Timeline, Sample{
+		Second: len(g.Timeline) + 1,
+		WPM:    float64(g.correctChars()) / 5.0 / end.Minutes(),
+		RawWPM: float64(chars) / 5.0 / interval.Minutes(),
+		Errors: errors,
+	})
+	g.sampledChars = g.TotalChars
+	g.sampledErrors = g.ErrorChars
+}
+
+// finish ends the game, closing the timeline with the final partial second
+func (g *Game) finish() {
+	g.State = StateFinished
+	if g.StartTime.IsZero() {
+		return
+	}
+
+	// Elapsed was last set on the previous tick, before the final keystrokes
+	g.Elapsed = g.clock().Sub(g.StartTime)
+	if g.Mode == ModeTimed {
+		g.Elapsed = min(g.Elapsed, g.Duration)
+	}
+
+	g.recordSamples()
+	partial := g.Elapsed - time.Duration(len(g.Timeline))*time.Second
+	// Skip slivers too short to give a meaningful rate
+	if partial >= 100*time.Millisecond && g.TotalChars > g.sampledChars {
+		g.addSample(partial)
+	}
+}
+
+// Consistency rates how steady the raw speed was across the test, from 0 to 100.
+// It maps the coefficient of variation of the per-second raw WPM onto a percentage
+// the same way MonkeyType does, so that 100% means a perfectly even pace.
+func (g *Game) Consistency() float64 {
+	if len(g.Timeline) < 2 {
+		return 0
+	}
+
+	var sum float64
+	for _, s := range g.Timeline {
+		sum += s.RawWPM
+	}
+	mean := sum / float64(len(g.Timeline))
+	if mean == 0 {
+		return 0
+	}
+
+	var variance float64
+	for _, s := range g.Timeline {
+		variance += (s.RawWPM - mean) * (s.RawWPM - mean)
+	}
+	cv := math.Sqrt(variance/float64(len(g.Timeline))) / mean
+
+	return 100 * (1 - math.Tanh(cv+math.Pow(cv, 3)/3+math.Pow(cv, 5)/5))
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimelineSamples(t *testing.T) {
+	g := NewWords(10, 0, 0, nil)
+	g.Words = []string{"hello", "world", "again"}
+
+	for _, c := range "hello" {
+		g.HandleChar(c)
+	}
+	g.HandleSpace()
+	g.Elapsed = 1 * time.Second
+	g.recordSamples()
+
+	g.HandleChar('x')
+	g.Elapsed = 2500 * time.Millisecond
+	g.recordSamples()
+
+	if len(g.Timeline) != 2 {
+		t.Fatalf("Expected 2 samples after 2.5s, got %d", len(g.Timeline))
+	}
+
+	first := g.Timeline[0]
+	// 6 chars in one second = 72 wpm
+	if first.Second != 1 || first.RawWPM != 72 || first.WPM != 72 || first.Errors != 0 {
+		t.Errorf("Unexpected first sample %+v", first)
+	}
+
+	second := g.Timeline[1]
+	if second.RawWPM != 12 || second.Errors != 1 {
+		t.Errorf("Expected 1 wrong char in the second sample, got %+v", second)
+	}
+	if second.WPM != 36 {
+		t.Errorf("Expected cumulative WPM 36 after 2s, got %.1f", second.WPM)
+	}
+}
+
+func TestFinishAddsPartialSample(t *testing.T) {
+	g := NewWords(1, 0, 0, nil)
+	g.Words = []string{"hi"}
+	g.StartTime = time.Now().Add(-1500 * time.Millisecond)
+	g.Elapsed = 1500 * time.Millisecond
+	g.recordSamples()
+
+	g.HandleChar('h')
+	g.HandleChar('i')
+	g.HandleSpace()
+
+	if g.State != StateFinished {
+		t.Fatal("Expected game to finish")
+	}
+	if len(g.Timeline) != 2 {
+		t.Fatalf("Expected a full and a partial sample, got %d", len(g.Timeline))
+	}
+	// 3 chars in half a second = 72 wpm, less the time the test took to run
+	if g.Timeline[1].RawWPM > 72 || g.Timeline[1].RawWPM < 70 {
+		t.Errorf("Expected partial sample raw WPM 72, got %.1f", g.Timeline[1].RawWPM)
+	}
+}
+
+func TestFinishCountsTimeSinceLastTick(t *testing.T) {
+	g := NewWords(1, 0, 0, nil)
+	g.Words = []string{"hi"}
+	// The last tick came at one second; the test ends 50ms later
+	g.StartTime = time.Now().Add(-1050 * time.Millisecond)
+	g.Elapsed = time.Second
+	g.recordSamples()
+
+	g.HandleChar('h')
+	g.HandleChar('i')
+	g.HandleSpace()
+
+	if g.Elapsed < 1050*time.Millisecond {
+		t.Errorf("Expected elapsed time to be refreshed on finish, got %v", g.Elapsed)
+	}
+	if len(g.Timeline) != 1 {
+		t.Fatalf("Expected only the full second to be sampled, got %d samples", len(g.Timeline))
+	}
+
+	// Keystrokes in a longer final stretch after the last tick get a sample
+	g = NewWords(1, 0, 0, nil)
+	g.Words = []string{"hi"}
+	g.StartTime = time.Now().Add(-1300 * time.Millisecond)
+	g.Elapsed = time.Second
+	g.recordSamples()
+
+	g.HandleChar('h')
+	g.HandleChar('i')
+	g.HandleSpace()
+
+	if len(g.Timeline) != 2 {
+		t.Fatalf("Expected a sample for the last 300ms, got %d samples", len(g.Timeline))
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	g := &Game{}
+	if g.Consistency() != 0 {
+		t.Error("Expected 0 consistency without samples")
+	}
+
+	g.Timeline = []Sample{{RawWPM: 60}, {RawWPM: 60}, {RawWPM: 60}}
+	if c := g.Consistency(); c != 100 {
+		t.Errorf("Expected 100%% consistency for an even pace, got %.1f", c)
+	}
+
+	g.Timeline = []Sample{{RawWPM: 40}, {RawWPM: 80}, {RawWPM: 40}, {RawWPM: 80}}
+	c := g.Consistency()
+	if c <= 0 || c >= 80 {
+		t.Errorf("Expected an uneven pace to score well below 100%%, got %.1f", c)
+	}
+}
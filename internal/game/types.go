@@ -14,14 +14,18 @@ const (
 	StateDifficultySelect
 	StateComplexitySelect
 	StateStats
+	StateNGrams
 	StateHeatmap
 	StateCustomWordList
 	StateSettings
 	StateCursorSelect
 	StateColorSelect
 	StateChallenges
+	StateQuoteSelect
+	StateHistory
 	StatePlaying
 	StateFinished
+	StateReplay
 )
 
 // Mode represents the type of game
@@ -31,18 +35,33 @@ const (
 	ModeTimed Mode = iota
 	ModeWords
 	ModeZen
+	ModeQuote
 )
 
 // ErrorType categorizes different types of typing errors
 type ErrorType int
 
 const (
-	ErrorWrongChar ErrorType = iota
-	ErrorExtraChar
-	ErrorMissingChar
-	ErrorTransposition
+	ErrorWrongChar     ErrorType = iota // Substitution: a different character was typed
+	ErrorExtraChar                      // Insertion: a character that isn't in the word
+	ErrorMissingChar                    // Omission: a character of the word was skipped
+	ErrorTransposition                  // Two adjacent characters were swapped
 )
 
+// String returns a string representation of the error type
+func (e ErrorType) String() string {
+	switch e {
+	case ErrorExtraChar:
+		return "extra"
+	case ErrorMissingChar:
+		return "missing"
+	case ErrorTransposition:
+		return "swapped"
+	default:
+		return "wrong"
+	}
+}
+
 // TypingError represents a single typing error with details
 type TypingError struct {
 	ExpectedChar rune
@@ -51,4 +70,5 @@ type TypingError struct {
 	WordIndex    int
 	Timestamp    time.Time
 	ErrorType    ErrorType
+	Corrected    bool // Fixed with backspace before the word was submitted
 }
@@ -0,0 +1,47 @@
+package keyboard
+
+// Emulation makes a QWERTY keyboard type as if it had another layout: each
+// key types what the key in the same position types on the target layout,
+// with and without shift. It lets someone learn a new layout without
+// changing the system keymap.
+type Emulation struct {
+	Layout Layout
+	keys   map[rune]rune // QWERTY character -> target character
+}
+
+// NewEmulation creates an emulation of layout on a QWERTY keyboard
+func NewEmulation(layout Layout) *Emulation {
+	e := &Emulation{Layout: layout, keys: make(map[rune]rune)}
+	for i := range QWERTY.Rows {
+		target := layout.Keys(i)
+		for col, key := range QWERTY.Keys(i) {
+			if col >= len(target) {
+				break
+			}
+			e.keys[rune0(key)] = rune0(target[col])
+			// Leave the shifted key alone if the target has nothing on it
+			if shifted := layout.Shifted(target[col]); shifted != target[col] {
+				e.keys[rune0(QWERTY.Shifted(key))] = rune0(shifted)
+			}
+		}
+	}
+	return e
+}
+
+// Translate returns the character the target layout types for a key pressed
+// on QWERTY. Characters not on the keyboard pass through unchanged.
+func (e *Emulation) Translate(r rune) rune {
+	if target, ok := e.keys[r]; ok {
+		return target
+	}
+	return r
+}
+
+// rune0 returns the first character of a key, which Validate makes sure is
+// its only one
+func rune0(key string) rune {
+	for _, r := range key {
+		return r
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package keyboard
+
+import "testing"
+
+func TestEmulationTranslate(t *testing.T) {
+	tests := []struct {
+		layout   Layout
+		input    rune
+		expected rune
+	}{
+		{Colemak, 'e', 'f'},
+		{Colemak, 'k', 'e'},
+		{Colemak, ';', 'o'},
+		{Colemak, 'K', 'E'},
+		{Colemak, ':', 'O'},
+		{Colemak, 'a', 'a'},
+		{Dvorak, 's', 'o'},
+		{Dvorak, 'q', '\''},
+		{Dvorak, 'z', ';'},
+		{Dvorak, 'Q', '"'},
+		{Dvorak, 'Z', ':'},
+		{Dvorak, 'W', '<'},
+		{Dvorak, '{', '?'},
+		{Dvorak, '[', '/'},
+		{Dvorak, '\'', '-'},
+		{Dvorak, '"', '_'},
+		{Dvorak, '-', '['},
+		{Dvorak, '5', '5'},
+		{Dvorak, '!', '!'},
+		{Dvorak, 'é', 'é'},
+		{QWERTZ, 'y', 'z'},
+		{QWERTZ, 'z', 'y'},
+		{QWERTZ, '-', 'ß'},
+		{QWERTZ, '@', '"'},
+		{QWERTZ, '"', 'Ä'},
+		{AZERTY, 'm', ','},
+		{AZERTY, 'M', '?'},
+		{AZERTY, '2', 'é'},
+		{AZERTY, '@', '2'},
+		{QWERTZ, '[', 'ü'},
+		{QWERTZ, '{', 'Ü'},
+		{QWERTY, 'q', 'q'},
+	}
+
+	for _, tt := range tests {
+		got := NewEmulation(tt.layout).Translate(tt.input)
+		if got != tt.expected {
+			t.Errorf("%s: Translate(%q) = %q, expected %q", tt.layout.Name, tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestEmulationCoversLayout(t *testing.T) {
+	// Everything the target layout types, with or without shift, must be
+	// reachable from some QWERTY key
+	for _, layout := range Builtin() {
+		e := NewEmulation(layout)
+		reachable := make(map[rune]bool)
+		for i := range QWERTY.Rows {
+			for _, key := range QWERTY.Keys(i) {
+				reachable[e.Translate(rune0(key))] = true
+				reachable[e.Translate(rune0(QWERTY.Shifted(key)))] = true
+			}
+		}
+		for i := range layout.Rows {
+			for _, key := range layout.Keys(i) {
+				for _, char := range []string{key, layout.Shifted(key)} {
+					if !reachable[rune0(char)] {
+						t.Errorf("%s: %q can't be typed", layout.Name, char)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package keyboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Finger is one of the eight fingers that type on the main rows. Thumbs only
+// ever press space, so they aren't tracked.
+type Finger int
+
+const (
+	LeftPinky Finger = iota
+	LeftRing
+	LeftMiddle
+	LeftIndex
+	RightIndex
+	RightMiddle
+	RightRing
+	RightPinky
+)
+
+// Fingers lists every finger from left to right
+var Fingers = []Finger{LeftPinky, LeftRing, LeftMiddle, LeftIndex, RightIndex, RightMiddle, RightRing, RightPinky}
+
+var fingerNames = []string{"left pinky", "left ring", "left middle", "left index", "right index", "right middle", "right ring", "right pinky"}
+
+// String returns the finger's name, e.g. "left ring"
+func (f Finger) String() string {
+	if f < 0 || int(f) >= len(fingerNames) {
+		return "unknown"
+	}
+	return fingerNames[f]
+}
+
+// ParseFinger parses a finger name as returned by Finger.String. Hyphens or
+// underscores may stand in for the space.
+func ParseFinger(s string) (Finger, error) {
+	name := strings.ToLower(strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSpace(s)))
+	for i, n := range fingerNames {
+		if n == name {
+			return Finger(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown finger %q (want e.g. left pinky or right index)", s)
+}
+
+// MarshalText writes the finger by name, so layouts files can use names
+func (f Finger) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText reads a finger name
+func (f *Finger) UnmarshalText(text []byte) error {
+	finger, err := ParseFinger(string(text))
+	if err != nil {
+		return err
+	}
+	*f = finger
+	return nil
+}
+
+// Hand is the hand a finger belongs to
+type Hand int
+
+const (
+	LeftHand Hand = iota
+	RightHand
+)
+
+// String returns "left" or "right"
+func (h Hand) String() string {
+	if h == RightHand {
+		return "right"
+	}
+	return "left"
+}
+
+// Hand returns the hand the finger belongs to
+func (f Finger) Hand() Hand {
+	if f >= RightIndex {
+		return RightHand
+	}
+	return LeftHand
+}
+
+// columnFinger returns the finger that presses the key in column col in
+// standard touch typing: the index fingers take the two middle columns each
+// and the right pinky everything past the tenth
+func columnFinger(col int) Finger {
+	switch {
+	case col <= 2:
+		return Finger(col)
+	case col <= 4:
+		return LeftIndex
+	case col <= 6:
+		return RightIndex
+	case col <= 8:
+		return Finger(col - 2)
+	default:
+		return RightPinky
+	}
+}
+
+// Finger returns the finger that types char on this layout, with or without
+// shift: the layout's own assignment if it has one, otherwise the finger for
+// the key's column
+func (l Layout) Finger(char string) (Finger, bool) {
+	if f, ok := l.Fingers[char]; ok {
+		return f, true
+	}
+	if _, col, ok := l.Position(char); ok {
+		return columnFinger(col), true
+	}
+	if lower := strings.ToLower(char); lower != char {
+		return l.Finger(lower)
+	}
+	for key, shifted := range l.Shift {
+		if shifted == char {
+			return l.Finger(key)
+		}
+	}
+	return 0, false
+}
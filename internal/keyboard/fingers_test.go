@@ -0,0 +1,105 @@
+package keyboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayoutFinger(t *testing.T) {
+	tests := []struct {
+		layout Layout
+		char   string
+		finger Finger
+	}{
+		{QWERTY, "a", LeftPinky},
+		{QWERTY, "w", LeftRing},
+		{QWERTY, "c", LeftMiddle},
+		{QWERTY, "g", LeftIndex},
+		{QWERTY, "h", RightIndex},
+		{QWERTY, "u", RightIndex},
+		{QWERTY, "k", RightMiddle},
+		{QWERTY, "o", RightRing},
+		{QWERTY, "/", RightPinky},
+		{QWERTY, "1", LeftPinky},
+		{Colemak, "t", LeftIndex},
+		{Dvorak, "s", RightPinky},
+		{QWERTZ, "ä", RightPinky},
+		{QWERTY, "[", RightPinky},
+		{QWERTY, "'", RightPinky},
+		{QWERTY, "=", RightPinky},
+		{Dvorak, "/", RightPinky},
+		{QWERTY, "?", RightPinky},
+		{QWERTY, "!", LeftPinky},
+		{QWERTY, "{", RightPinky},
+		{QWERTY, "A", LeftPinky},
+		{AZERTY, "1", LeftPinky},
+		{QWERTZ, "ü", RightPinky},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.layout.Finger(tt.char)
+		if !ok || got != tt.finger {
+			t.Errorf("%s: Finger(%q) = %v, %v, expected %v", tt.layout.Name, tt.char, got, ok, tt.finger)
+		}
+	}
+
+	if _, ok := QWERTY.Finger(" "); ok {
+		t.Error("Space should not be assigned to a finger")
+	}
+
+	custom := QWERTY
+	custom.Fingers = map[string]Finger{"6": LeftIndex, "'": RightPinky}
+	if f, _ := custom.Finger("6"); f != LeftIndex {
+		t.Errorf("Expected the override to put 6 on the left index, got %v", f)
+	}
+	if f, ok := custom.Finger("'"); !ok || f != RightPinky {
+		t.Errorf("Expected ' on the right pinky, got %v, %v", f, ok)
+	}
+}
+
+func TestFingerHand(t *testing.T) {
+	for _, f := range Fingers {
+		expected := LeftHand
+		if f >= RightIndex {
+			expected = RightHand
+		}
+		if f.Hand() != expected {
+			t.Errorf("%v.Hand() = %v, expected %v", f, f.Hand(), expected)
+		}
+	}
+}
+
+func TestParseFinger(t *testing.T) {
+	for _, f := range Fingers {
+		got, err := ParseFinger(f.String())
+		if err != nil || got != f {
+			t.Errorf("ParseFinger(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+
+	if f, err := ParseFinger("Right_Ring"); err != nil || f != RightRing {
+		t.Errorf("Expected right ring, got %v, %v", f, err)
+	}
+	if _, err := ParseFinger("thumb"); err == nil {
+		t.Error("Expected an error for thumb")
+	}
+}
+
+func TestLoadFingers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layouts.json")
+	os.WriteFile(path, []byte(`{"layouts": [{"name": "mine", "rows": ["1234567890", "qwertyuiop", "asdfghjkl;", "zxcvbnm,./"], "fingers": {"b": "left-index", "6": "left index"}}]}`), 0644)
+
+	layouts, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if f, _ := layouts[0].Finger("6"); f != LeftIndex {
+		t.Errorf("Expected 6 on the left index, got %v", f)
+	}
+
+	os.WriteFile(path, []byte(`{"layouts": [{"name": "mine", "rows": ["1234567890", "qwertyuiop", "asdfghjkl;", "zxcvbnm,./"], "fingers": {"b": "thumb"}}]}`), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for an unknown finger")
+	}
+}
@@ -0,0 +1,236 @@
+// Package keyboard describes keyboard layouts: which character sits on which
+// key, row by row, so screens and statistics can follow the layout the user
+// actually types on.
+package keyboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Row indexes into Layout.Rows
+const (
+	RowNumbers = iota
+	RowTop
+	RowHome
+	RowBottom
+	rowCount
+)
+
+// Layout maps the keys of a row-staggered keyboard to the characters they
+// type. Each row lists the unshifted characters of its keys from left to
+// right, starting at the key under the left pinky.
+type Layout struct {
+	Name string   `json:"name"`
+	Rows []string `json:"rows"` // Number, top, home and bottom row
+
+	// Extra lists, for each row, the keys to the right of the ones in Rows,
+	// such as "-=" on QWERTY's number row. The heatmap leaves them out.
+	Extra []string `json:"extra,omitempty"`
+
+	// Shift maps keys to what they type with shift held. Letters without an
+	// entry type their upper case. See Layout.Shifted.
+	Shift map[string]string `json:"shift,omitempty"`
+
+	// Fingers assigns keys to fingers where the layout departs from standard
+	// touch typing, or adds keys beyond the rows. See Layout.Finger.
+	Fingers map[string]Finger `json:"fingers,omitempty"`
+}
+
+// usShift is the shift level of the US keyboard, shared by the layouts that
+// only rearrange its keys
+var usShift = map[string]string{
+	"1": "!", "2": "@", "3": "#", "4": "$", "5": "%", "6": "^", "7": "&", "8": "*", "9": "(", "0": ")",
+	"-": "_", "=": "+", "[": "{", "]": "}", ";": ":", "'": "\"", ",": "<", ".": ">", "/": "?", "\\": "|",
+}
+
+// Built-in layouts. Each row lists the ten keys of the main block; the keys
+// past them are in Extra.
+var (
+	QWERTY = Layout{
+		Name:  "qwerty",
+		Rows:  []string{"1234567890", "qwertyuiop", "asdfghjkl;", "zxcvbnm,./"},
+		Extra: []string{"-=", "[]", "'\\", ""},
+		Shift: usShift,
+	}
+	Dvorak = Layout{
+		Name:  "dvorak",
+		Rows:  []string{"1234567890", "',.pyfgcrl", "aoeuidhtns", ";qjkxbmwvz"},
+		Extra: []string{"[]", "/=", "-\\", ""},
+		Shift: usShift,
+	}
+	Colemak = Layout{
+		Name:  "colemak",
+		Rows:  []string{"1234567890", "qwfpgjluy;", "arstdhneio", "zxcvbkm,./"},
+		Extra: []string{"-=", "[]", "'\\", ""},
+		Shift: usShift,
+	}
+	Workman = Layout{
+		Name:  "workman",
+		Rows:  []string{"1234567890", "qdrwbjfup;", "ashtgyneoi", "zxmcvkl,./"},
+		Extra: []string{"-=", "[]", "'\\", ""},
+		Shift: usShift,
+	}
+	AZERTY = Layout{
+		Name:  "azerty",
+		Rows:  []string{"&é\"'(-è_çà", "azertyuiop", "qsdfghjklm", "wxcvbn,;:!"},
+		Extra: []string{")=", "^$", "ù*", ""},
+		Shift: map[string]string{
+			"&": "1", "é": "2", "\"": "3", "'": "4", "(": "5", "-": "6", "è": "7", "_": "8", "ç": "9", "à": "0",
+			")": "°", "=": "+", "^": "¨", "$": "£", "ù": "%", ",": "?", ";": ".", ":": "/", "!": "§", "*": "µ",
+		},
+	}
+	QWERTZ = Layout{
+		Name:  "qwertz",
+		Rows:  []string{"1234567890", "qwertzuiop", "asdfghjklö", "yxcvbnm,.-"},
+		Extra: []string{"ß´", "ü+", "ä#", ""},
+		Shift: map[string]string{
+			"1": "!", "2": "\"", "3": "§", "4": "$", "5": "%", "6": "&", "7": "/", "8": "(", "9": ")", "0": "=",
+			"ß": "?", "´": "`", "+": "*", "#": "'", ",": ";", ".": ":", "-": "_",
+		},
+	}
+)
+
+// Builtin returns the layouts that ship with ktype, QWERTY first
+func Builtin() []Layout {
+	return []Layout{QWERTY, Dvorak, Colemak, Workman, AZERTY, QWERTZ}
+}
+
+// Row returns the characters of row i, or nil if the layout doesn't have it
+func (l Layout) Row(i int) []string {
+	if i < 0 || i >= len(l.Rows) {
+		return nil
+	}
+	keys := make([]string, 0, utf8.RuneCountInString(l.Rows[i]))
+	for _, r := range l.Rows[i] {
+		keys = append(keys, string(r))
+	}
+	return keys
+}
+
+// Keys returns the characters of row i including the extra keys at its end
+func (l Layout) Keys(i int) []string {
+	keys := l.Row(i)
+	if i >= 0 && i < len(l.Extra) {
+		for _, r := range l.Extra[i] {
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
+
+// Shifted returns what the key that types char types with shift held. Keys
+// the layout has no shifted character for type the same with or without it.
+func (l Layout) Shifted(char string) string {
+	if shifted, ok := l.Shift[char]; ok {
+		return shifted
+	}
+	return strings.ToUpper(char)
+}
+
+// Position returns the row and column of the key that types char, counting
+// the extra keys as the columns after the row's last one
+func (l Layout) Position(char string) (row, col int, ok bool) {
+	for row := range l.Rows {
+		for col, key := range l.Keys(row) {
+			if key == char {
+				return row, col, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Validate reports whether the layout is complete and unambiguous
+func (l Layout) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("layout has no name")
+	}
+	if len(l.Rows) != rowCount {
+		return fmt.Errorf("layout %q has %d rows (want %d: number, top, home and bottom)", l.Name, len(l.Rows), rowCount)
+	}
+
+	if len(l.Extra) > rowCount {
+		return fmt.Errorf("layout %q has extra keys for %d rows (want at most %d)", l.Name, len(l.Extra), rowCount)
+	}
+
+	seen := make(map[string]bool)
+	for i := range l.Rows {
+		for _, key := range l.Keys(i) {
+			if seen[key] {
+				return fmt.Errorf("layout %q has %q on more than one key", l.Name, key)
+			}
+			seen[key] = true
+		}
+	}
+
+	for key, shifted := range l.Shift {
+		if !seen[key] {
+			return fmt.Errorf("layout %q shifts %q, which is not one of its keys", l.Name, key)
+		}
+		if utf8.RuneCountInString(shifted) != 1 {
+			return fmt.Errorf("layout %q shifts %q to %q, which is not a single character", l.Name, key, shifted)
+		}
+		if seen[shifted] {
+			return fmt.Errorf("layout %q has %q on more than one key", l.Name, shifted)
+		}
+		seen[shifted] = true
+	}
+
+	for key := range l.Fingers {
+		if utf8.RuneCountInString(key) != 1 {
+			return fmt.Errorf("layout %q assigns a finger to %q, which is not a single key", l.Name, key)
+		}
+	}
+	return nil
+}
+
+// layoutsFile is the form of a user-defined layouts file
+type layoutsFile struct {
+	Layouts []Layout `json:"layouts"`
+}
+
+// Load reads user-defined layouts from a JSON file of the form
+// {"layouts": [{"name": "...", "rows": ["...", "...", "...", "..."]}]},
+// optionally with "extra", "shift" and "fingers" as in Layout.
+// A missing file holds no layouts. Names are lowercased.
+func Load(path string) ([]Layout, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file layoutsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid layouts file: %w", err)
+	}
+
+	for i := range file.Layouts {
+		file.Layouts[i].Name = strings.ToLower(strings.TrimSpace(file.Layouts[i].Name))
+		if err := file.Layouts[i].Validate(); err != nil {
+			return nil, err
+		}
+		if _, builtin := Find(file.Layouts[i].Name, nil); builtin {
+			return nil, fmt.Errorf("layout %q is built in and can't be redefined", file.Layouts[i].Name)
+		}
+	}
+	return file.Layouts, nil
+}
+
+// Find returns the layout with the given name among the built-in layouts and
+// custom ones
+func Find(name string, custom []Layout) (Layout, bool) {
+	name = strings.ToLower(name)
+	for _, l := range append(Builtin(), custom...) {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Layout{}, false
+}
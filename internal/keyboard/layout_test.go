@@ -0,0 +1,138 @@
+package keyboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinLayoutsValid(t *testing.T) {
+	for _, l := range Builtin() {
+		if err := l.Validate(); err != nil {
+			t.Errorf("Built-in layout %s is invalid: %v", l.Name, err)
+		}
+		// Extra keys start past the same column everywhere, so columns mean
+		// the same finger on every layout
+		for i := range l.Rows {
+			if n := len(l.Row(i)); n != 10 {
+				t.Errorf("%s: row %d has %d keys, expected 10", l.Name, i, n)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		valid  bool
+	}{
+		{"qwerty", QWERTY, true},
+		{"no name", Layout{Name: "", Rows: QWERTY.Rows}, false},
+		{"missing row", Layout{Name: "short", Rows: QWERTY.Rows[:3]}, false},
+		{"duplicate key", Layout{Name: "dup", Rows: []string{"1234567890", "qwertyuiop", "asdfghjkla", "zxcvbnm,./"}}, false},
+		{"duplicate extra key", Layout{Name: "dup", Rows: QWERTY.Rows, Extra: []string{"-=", "[]", "a"}}, false},
+		{"shifts a missing key", Layout{Name: "shift", Rows: QWERTY.Rows, Shift: map[string]string{"'": "\""}}, false},
+		{"shifts to a key", Layout{Name: "shift", Rows: QWERTY.Rows, Shift: map[string]string{"1": "2"}}, false},
+		{"shifts to a string", Layout{Name: "shift", Rows: QWERTY.Rows, Shift: map[string]string{"1": "!!"}}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.layout.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, expected valid=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		layout   Layout
+		char     string
+		row, col int
+	}{
+		{QWERTY, "f", RowHome, 3},
+		{Dvorak, "u", RowHome, 3},
+		{Colemak, "t", RowHome, 3},
+		{AZERTY, "a", RowTop, 0},
+		{AZERTY, "é", RowNumbers, 1},
+		{QWERTZ, "ö", RowHome, 9},
+		{QWERTZ, "ü", RowTop, 10},
+	}
+
+	for _, tt := range tests {
+		row, col, ok := tt.layout.Position(tt.char)
+		if !ok || row != tt.row || col != tt.col {
+			t.Errorf("%s: Position(%q) = %d, %d, %v, expected %d, %d", tt.layout.Name, tt.char, row, col, ok, tt.row, tt.col)
+		}
+	}
+
+	if _, _, ok := QWERTY.Position("ü"); ok {
+		t.Error("ü should not be on the QWERTY layout")
+	}
+}
+
+func TestKeysAndShifted(t *testing.T) {
+	if keys := Dvorak.Keys(RowTop); len(keys) != 12 || keys[10] != "/" || keys[11] != "=" {
+		t.Errorf("Expected the Dvorak top row to end in / and =, got %v", keys)
+	}
+	if keys := QWERTY.Keys(RowBottom); len(keys) != 10 {
+		t.Errorf("Expected 10 keys on the QWERTY bottom row, got %v", keys)
+	}
+
+	tests := []struct {
+		layout   Layout
+		char     string
+		expected string
+	}{
+		{QWERTY, "a", "A"},
+		{QWERTY, "/", "?"},
+		{QWERTZ, "ü", "Ü"},
+		{AZERTY, "&", "1"},
+		{AZERTY, "ù", "%"},
+		{Layout{Name: "bare"}, ";", ";"},
+	}
+	for _, tt := range tests {
+		if got := tt.layout.Shifted(tt.char); got != tt.expected {
+			t.Errorf("%s: Shifted(%q) = %q, expected %q", tt.layout.Name, tt.char, got, tt.expected)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	layouts, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil || layouts != nil {
+		t.Errorf("Expected no layouts and no error for a missing file, got %v, %v", layouts, err)
+	}
+
+	path := filepath.Join(dir, "layouts.json")
+	os.WriteFile(path, []byte(`{"layouts": [{"name": " Halmak ", "rows": ["1234567890", "wlrbz;qudj", "shnt,.aeoi", "fmvc/gpxky"]}]}`), 0644)
+	layouts, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(layouts) != 1 || layouts[0].Name != "halmak" {
+		t.Fatalf("Expected the halmak layout, got %+v", layouts)
+	}
+
+	if l, ok := Find("HALMAK", layouts); !ok || l.Name != "halmak" {
+		t.Error("Expected to find the custom layout case-insensitively")
+	}
+	if _, ok := Find("halmak", nil); ok {
+		t.Error("Custom layouts should only be found when passed in")
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"layouts": [{"name": "dvorak", "rows": ["1234567890", "qwertyuiop", "asdfghjkl;", "zxcvbnm,./"]}]}`,
+		`{"layouts": [{"name": "short", "rows": ["1234567890", "qwertyuiop"]}]}`,
+	}
+	for _, content := range invalid {
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected an error loading %s", content)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A backup is a tar.gz archive holding a manifest followed by the profile's
+// files. The manifest names the archive format and its version and records the
+// size and checksum of every file, so a damaged or foreign archive is rejected
+// before anything is restored.
+
+const (
+	backupFormat   = "ktype-backup"
+	backupVersion  = 1
+	manifestName   = "manifest.json"
+	maxBackupEntry = 512 << 20 // Largest file accepted from an archive
+)
+
+// profileFile is a file that belongs in a backup
+type profileFile struct {
+	name     string
+	settings bool // Lives in the config directory rather than the data directory
+}
+
+// profileFiles lists the files a backup holds. Keystroke logs are left out:
+// they can run to thousands of files and replays work without them.
+var profileFiles = []profileFile{
+	{"config.json", true},
+	{"wordlists.json", true},
+	{"layouts.json", true},
+	{"history.jsonl", false},
+	{"scores.json", false}, // Only present if the history was never converted
+	{"heatmap.json", false},
+	{"ngrams.json", false},
+	{"challenges.json", false},
+}
+
+// path returns where the file lives under root
+func (f profileFile) path(root Root) string {
+	if f.settings {
+		return root.configPath(f.name)
+	}
+	return root.dataPath(f.name)
+}
+
+// findProfileFile returns the profile file with the given name
+func findProfileFile(name string) (profileFile, bool) {
+	for _, f := range profileFiles {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return profileFile{}, false
+}
+
+// Manifest describes the contents of a backup
+type Manifest struct {
+	Format  string       `json:"format"`
+	Version int          `json:"version"`
+	Created time.Time    `json:"created"`
+	Files   []BackupFile `json:"files"`
+}
+
+// BackupFile is a file listed in a backup's manifest
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Backup is a validated backup archive read into memory
+type Backup struct {
+	Manifest Manifest
+	files    map[string][]byte
+}
+
+// Backup writes an archive of the profile to w. Each file is read under its
+// lock, so writes from running instances never end up half in the archive.
+func (r Root) Backup(w io.Writer) (Manifest, error) {
+	manifest := Manifest{Format: backupFormat, Version: backupVersion, Created: time.Now()}
+	files := make(map[string][]byte)
+
+	for _, f := range profileFiles {
+		path := f.path(r)
+		if !exists(path) {
+			continue
+		}
+		var data []byte
+		err := withLock(path, func() error {
+			var err error
+			data, err = os.ReadFile(path)
+			return err
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, BackupFile{Name: f.name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+		files[f.name] = data
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := writeTarEntry(tw, manifestName, manifestData, manifest.Created); err != nil {
+		return Manifest{}, err
+	}
+	for _, f := range manifest.Files {
+		if err := writeTarEntry(tw, f.Name, files[f.Name], manifest.Created); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, gz.Close()
+}
+
+// writeTarEntry adds a regular file to an archive
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// BackupPath returns where a backup taken at t is kept by default, in the
+// backups directory next to the history
+func (r Root) BackupPath(t time.Time) string {
+	return filepath.Join(r.DataDir, "backups", "ktype-backup-"+t.Format("20060102-150405")+".tar.gz")
+}
+
+// SaveBackup writes an archive of the profile to path, creating its directory
+func (r Root) SaveBackup(path string) (Manifest, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Manifest{}, err
+	}
+
+	var buf bytes.Buffer
+	manifest, err := r.Backup(&buf)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return manifest, writeFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// ReadBackup reads and validates a backup archive. It fails unless the
+// archive starts with a manifest this version understands and holds exactly
+// the files listed in it, each intact and readable.
+func ReadBackup(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a ktype backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	data, name, err := readTarEntry(tr)
+	if err != nil {
+		return nil, err
+	}
+	if name != manifestName {
+		return nil, fmt.Errorf("not a ktype backup: %s is missing", manifestName)
+	}
+
+	b := &Backup{files: make(map[string][]byte)}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil || b.Manifest.Format != backupFormat {
+		return nil, fmt.Errorf("not a ktype backup: unrecognised %s", manifestName)
+	}
+	if b.Manifest.Version > backupVersion {
+		return nil, fmt.Errorf("backup was made by a newer ktype (version %d, this one reads up to %d)", b.Manifest.Version, backupVersion)
+	}
+
+	listed := make(map[string]BackupFile)
+	for _, f := range b.Manifest.Files {
+		if _, ok := findProfileFile(f.Name); !ok {
+			return nil, fmt.Errorf("backup lists unknown file %q", f.Name)
+		}
+		listed[f.Name] = f
+	}
+
+	for {
+		data, name, err := readTarEntry(tr)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		f, ok := listed[name]
+		if !ok {
+			return nil, fmt.Errorf("backup holds %q, which its manifest doesn't list", name)
+		}
+		if _, dup := b.files[name]; dup {
+			return nil, fmt.Errorf("backup holds %q twice", name)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("%s in the backup is damaged (checksum mismatch)", name)
+		}
+		if err := checkProfileFile(name, data); err != nil {
+			return nil, fmt.Errorf("%s in the backup can't be read: %w", name, err)
+		}
+		b.files[name] = data
+	}
+
+	for name := range listed {
+		if _, ok := b.files[name]; !ok {
+			return nil, fmt.Errorf("backup is incomplete: %s is missing", name)
+		}
+	}
+	return b, nil
+}
+
+// readTarEntry returns the next regular file of an archive, or io.EOF
+func readTarEntry(tr *tar.Reader) ([]byte, string, error) {
+	header, err := tr.Next()
+	if err == io.EOF {
+		return nil, "", io.EOF
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("backup is damaged: %w", err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, "", fmt.Errorf("backup holds %q, which is not a regular file", header.Name)
+	}
+	if header.Size > maxBackupEntry {
+		return nil, "", fmt.Errorf("backup holds %q, which is too large", header.Name)
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, "", fmt.Errorf("backup is damaged: %w", err)
+	}
+	return data, header.Name, nil
+}
+
+// checkProfileFile reports whether data is a readable copy of the named file
+func checkProfileFile(name string, data []byte) error {
+	if name != "history.jsonl" {
+		if !json.Valid(data) {
+			return errors.New("not valid JSON")
+		}
+		return nil
+	}
+
+	line, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	var header historyHeader
+	if json.Unmarshal(line, &header) != nil || header.Format != historyFormat {
+		return errors.New("missing history header")
+	}
+	return nil
+}
+
+// OpenBackup reads and validates the backup archive at path
+func OpenBackup(path string) (*Backup, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBackup(f)
+}
+
+// RestoreMode selects how a backup is restored
+type RestoreMode int
+
+const (
+	RestoreMerge   RestoreMode = iota // Add the backup's history to the current profile
+	RestoreReplace                    // Make the profile a copy of the backup
+)
+
+// String returns the name used on the command line
+func (m RestoreMode) String() string {
+	switch m {
+	case RestoreReplace:
+		return "replace"
+	default:
+		return "merge"
+	}
+}
+
+// ParseRestoreMode parses a restore mode name
+func ParseRestoreMode(s string) (RestoreMode, error) {
+	switch s {
+	case "merge":
+		return RestoreMerge, nil
+	case "replace":
+		return RestoreReplace, nil
+	}
+	return RestoreMerge, fmt.Errorf("unknown restore mode %q (want merge or replace)", s)
+}
+
+// RestoreResult counts what a restore changed
+type RestoreResult struct {
+	Files      int // Files replaced
+	Scores     int // Scores added
+	Keys       int // Heatmap keys updated
+	NGrams     int // N-grams updated
+	Challenges int // Challenges added or updated
+	WordLists  int // Word lists added
+}
+
+// Restore applies a backup to the profile.
+//
+// RestoreReplace swaps every profile file for its copy in the backup and sets
+// aside files the backup doesn't have; the replaced files are kept as
+// <file>.bak. RestoreMerge keeps the current settings and adds the backup's
+// scores, heatmap, n-grams, challenges and word lists the same way import does.
+func (r Root) Restore(b *Backup, mode RestoreMode) (RestoreResult, error) {
+	if err := r.ensure(); err != nil {
+		return RestoreResult{}, err
+	}
+	if mode == RestoreReplace {
+		return r.replaceWith(b)
+	}
+	return r.mergeFrom(b)
+}
+
+// replaceWith overwrites the profile with the backup's files
+func (r Root) replaceWith(b *Backup) (RestoreResult, error) {
+	var result RestoreResult
+	for _, f := range profileFiles {
+		path := f.path(r)
+		data, inBackup := b.files[f.name]
+		err := withLock(path, func() error {
+			current, err := os.ReadFile(path)
+			switch {
+			case err == nil && !inBackup:
+				return os.Rename(path, backupPath(path))
+			case err == nil:
+				if err := writeFileAtomic(backupPath(path), current, 0644); err != nil {
+					return err
+				}
+			case !errors.Is(err, os.ErrNotExist):
+				return err
+			}
+			if !inBackup {
+				return nil
+			}
+			return writeFileAtomic(path, data, 0644)
+		})
+		if err != nil {
+			return result, fmt.Errorf("restoring %s: %w", f.name, err)
+		}
+		if inBackup {
+			result.Files++
+		}
+	}
+	return result, nil
+}
+
+// mergeFrom adds the backup's data to the profile. The backup is unpacked
+// into a scratch profile first, so old file versions are upgraded by the
+// usual loaders.
+func (r Root) mergeFrom(b *Backup) (RestoreResult, error) {
+	var result RestoreResult
+
+	dir, err := os.MkdirTemp("", "ktype-restore-")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(dir)
+	scratch := NewRoot(dir)
+	for name, data := range b.files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Scores, err = NewLeaderboard(r).Merge(NewLeaderboard(scratch).Scores); err != nil {
+		return result, fmt.Errorf("merging scores: %w", err)
+	}
+
+	backupHeatmap := NewHeatmap(scratch)
+	stats := backupHeatmap.Stats()
+	backupHeatmap.Close()
+	heatmap := NewHeatmap(r)
+	result.Keys, err = heatmap.Merge(stats)
+	heatmap.Close()
+	if err != nil {
+		return result, fmt.Errorf("merging heatmap: %w", err)
+	}
+
+	var ngrams []NGramStats
+	for _, stat := range NewNGrams(scratch).Stats {
+		ngrams = append(ngrams, *stat)
+	}
+	if result.NGrams, err = NewNGrams(r).Merge(ngrams); err != nil {
+		return result, fmt.Errorf("merging n-grams: %w", err)
+	}
+
+	if result.Challenges, err = NewDailyChallenges(r).Merge(NewDailyChallenges(scratch).Challenges); err != nil {
+		return result, fmt.Errorf("merging challenges: %w", err)
+	}
+
+	if result.WordLists, err = NewWordListManager(r).Merge(NewWordListManager(scratch).Lists); err != nil {
+		return result, fmt.Errorf("merging word lists: %w", err)
+	}
+	return result, nil
+}
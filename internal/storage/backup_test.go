@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProfile fills a profile with one of everything a backup holds
+func testProfile(t *testing.T, wpm int, list string) Root {
+	t.Helper()
+	root := NewRoot(t.TempDir())
+
+	NewLeaderboard(root).AddScore(Score{WPM: wpm, Accuracy: 95, Mode: "time:30"})
+	NewConfigManager(root).SetSaveKeyLogs(true)
+	if err := NewWordListManager(root).AddList(list, "", []string{"alpha", "beta"}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHeatmap(root)
+	h.RecordHit("a")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	NewNGrams(root).Record([]NGramSample{{NGram: "th", Latency: 150 * time.Millisecond}})
+	NewDailyChallenges(root)
+	return root
+}
+
+func testBackup(t *testing.T, root Root) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := root.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBackupManifest(t *testing.T) {
+	root := testProfile(t, 60, "mine")
+	b, err := ReadBackup(bytes.NewReader(testBackup(t, root)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Manifest.Format != backupFormat || b.Manifest.Version != backupVersion {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+	var names []string
+	for _, f := range b.Manifest.Files {
+		names = append(names, f.Name)
+	}
+	want := "config.json wordlists.json history.jsonl heatmap.json ngrams.json challenges.json"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("backed up %q, want %q", got, want)
+	}
+}
+
+func TestRestoreReplace(t *testing.T) {
+	source := testProfile(t, 60, "mine")
+	data := testBackup(t, source)
+
+	target := testProfile(t, 90, "theirs")
+	b, err := ReadBackup(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := target.Restore(b, RestoreReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 6 {
+		t.Errorf("replaced %d files, want 6", result.Files)
+	}
+
+	lb := NewLeaderboard(target)
+	if lb.Count() != 1 || lb.Scores[0].WPM != 60 {
+		t.Errorf("scores after replace = %+v", lb.Scores)
+	}
+	if names := NewWordListManager(target).ListNames(); len(names) != 1 || names[0] != "mine" {
+		t.Errorf("word lists after replace = %v", names)
+	}
+	if _, err := os.Stat(backupPath(target.dataPath("history.jsonl"))); err != nil {
+		t.Error("replaced history should be kept as a backup")
+	}
+}
+
+func TestRestoreMerge(t *testing.T) {
+	source := testProfile(t, 60, "mine")
+	data := testBackup(t, source)
+
+	target := testProfile(t, 90, "theirs")
+	NewConfigManager(target).SetSaveKeyLogs(false)
+	b, err := ReadBackup(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := target.Restore(b, RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scores != 1 || result.WordLists != 1 {
+		t.Errorf("result = %+v, want 1 score and 1 word list", result)
+	}
+
+	if lb := NewLeaderboard(target); lb.Count() != 2 {
+		t.Errorf("merged history has %d scores, want 2", lb.Count())
+	}
+	if names := NewWordListManager(target).ListNames(); len(names) != 2 {
+		t.Errorf("merged word lists = %v", names)
+	}
+	if NewConfigManager(target).GetConfig().SaveKeyLogs {
+		t.Error("merge should keep the current settings")
+	}
+
+	// Merging the same backup again changes nothing
+	if result, _ = target.Restore(b, RestoreMerge); result != (RestoreResult{}) {
+		t.Errorf("second merge = %+v, want nothing", result)
+	}
+}
+
+// writeArchive builds a tar.gz from name and content pairs
+func writeArchive(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i := 0; i < len(entries); i += 2 {
+		if err := writeTarEntry(tw, entries[i], []byte(entries[i+1]), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestReadBackupRejects(t *testing.T) {
+	manifest := func(m Manifest) string {
+		data, _ := json.Marshal(m)
+		return string(data)
+	}
+	good := testBackup(t, testProfile(t, 60, "mine"))
+
+	// Change a file without updating the manifest
+	b, err := ReadBackup(bytes.NewReader(good))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Replace(b.files["config.json"], []byte("true"), []byte("fals"), 1)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not gzip", []byte("hello"), "not a ktype backup"},
+		{"no manifest", writeArchive(t, "config.json", "{}"), "manifest.json is missing"},
+		{"foreign manifest", writeArchive(t, manifestName, `{"format":"other"}`), "unrecognised"},
+		{"newer version", writeArchive(t, manifestName, manifest(Manifest{Format: backupFormat, Version: backupVersion + 1})), "newer ktype"},
+		{"unknown file", writeArchive(t, manifestName, manifest(Manifest{Format: backupFormat, Version: 1,
+			Files: []BackupFile{{Name: "../evil"}}})), "unknown file"},
+		{"unlisted file", writeArchive(t, manifestName, manifest(Manifest{Format: backupFormat, Version: 1}),
+			"config.json", "{}"), "doesn't list"},
+		{"missing file", writeArchive(t, manifestName, manifest(b.Manifest)), "missing"},
+		{"damaged file", writeArchive(t, manifestName, manifest(b.Manifest), "config.json", string(tampered)), "damaged"},
+	}
+
+	for _, tt := range tests {
+		_, err := ReadBackup(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -1,10 +1,7 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 )
 
@@ -35,27 +32,16 @@ type Challenge struct {
 
 // DailyChallenges manages daily challenges
 type DailyChallenges struct {
+	Version    int         `json:"version"`
 	Challenges []Challenge `json:"challenges"`
 	path       string
 }
 
 // NewDailyChallenges creates or loads daily challenges
-func NewDailyChallenges() *DailyChallenges {
+func NewDailyChallenges(root Root) *DailyChallenges {
 	dc := &DailyChallenges{
 		Challenges: []Challenge{},
-	}
-
-	// Get config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		configDir = os.TempDir()
-	}
-
-	ktypeDir := filepath.Join(configDir, "ktype")
-	if err := os.MkdirAll(ktypeDir, 0755); err != nil {
-		dc.path = "challenges.json"
-	} else {
-		dc.path = filepath.Join(ktypeDir, "challenges.json")
+		path:       root.dataPath("challenges.json"),
 	}
 
 	dc.load()
@@ -65,54 +51,63 @@ func NewDailyChallenges() *DailyChallenges {
 
 // load reads challenges from file
 func (dc *DailyChallenges) load() {
-	data, err := os.ReadFile(dc.path)
-	if err != nil {
-		return // File doesn't exist yet
-	}
-
-	if err := json.Unmarshal(data, dc); err != nil {
+	if err := loadJSON(dc.path, dc, challengesSchema); err != nil {
 		dc.Challenges = []Challenge{}
 	}
 }
 
 // save writes challenges to file
 func (dc *DailyChallenges) save() error {
-	data, err := json.MarshalIndent(dc, "", "  ")
-	if err != nil {
-		return err
-	}
+	dc.Version = challengesSchema.version()
+	return saveJSON(dc.path, dc)
+}
 
-	return os.WriteFile(dc.path, data, 0644)
+// update applies a change to the challenges on disk under the file lock, so
+// progress made in other running instances is kept rather than overwritten
+func (dc *DailyChallenges) update(apply func()) error {
+	return withLock(dc.path, func() error {
+		dc.Challenges = []Challenge{}
+		dc.load()
+		apply()
+		return dc.save()
+	})
+}
+
+// hasChallengesFor reports whether challenges were already generated for a date
+func (dc *DailyChallenges) hasChallengesFor(date string) bool {
+	for _, c := range dc.Challenges {
+		if c.Date == date {
+			return true
+		}
+	}
+	return false
 }
 
 // ensureDailyChallenges creates challenges for today if they don't exist
 func (dc *DailyChallenges) ensureDailyChallenges() {
 	today := time.Now().Format("2006-01-02")
+	if dc.hasChallengesFor(today) {
+		return
+	}
 
-	// Check if we already have challenges for today
-	hasToday := false
-	for _, c := range dc.Challenges {
-		if c.Date == today {
-			hasToday = true
-			break
+	dc.update(func() {
+		// Another instance may have generated them since we loaded
+		if dc.hasChallengesFor(today) {
+			return
 		}
-	}
 
-	// Remove old challenges (keep only last 7 days)
-	cutoff := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
-	var filtered []Challenge
-	for _, c := range dc.Challenges {
-		if c.Date >= cutoff {
-			filtered = append(filtered, c)
+		// Remove old challenges (keep only last 7 days)
+		cutoff := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+		var filtered []Challenge
+		for _, c := range dc.Challenges {
+			if c.Date >= cutoff {
+				filtered = append(filtered, c)
+			}
 		}
-	}
-	dc.Challenges = filtered
+		dc.Challenges = filtered
 
-	// Generate today's challenges if needed
-	if !hasToday {
 		dc.generateDailyChallenges(today)
-		dc.save()
-	}
+	})
 }
 
 // generateDailyChallenges creates 3 random challenges for the day
@@ -173,39 +168,39 @@ func (dc *DailyChallenges) GetTodaysChallenges() []Challenge {
 func (dc *DailyChallenges) UpdateProgress(wpm, accuracy, wordsTyped int) error {
 	today := time.Now().Format("2006-01-02")
 
-	for i := range dc.Challenges {
-		if dc.Challenges[i].Date != today {
-			continue
-		}
-
-		switch dc.Challenges[i].Type {
-		case ChallengeSpeed:
-			if wpm >= dc.Challenges[i].Target && !dc.Challenges[i].Completed {
-				dc.Challenges[i].Progress = wpm
-				dc.Challenges[i].Completed = true
-			}
-
-		case ChallengeAccuracy:
-			if accuracy >= dc.Challenges[i].Target && !dc.Challenges[i].Completed {
-				dc.Challenges[i].Progress = accuracy
-				dc.Challenges[i].Completed = true
-			}
-
-		case ChallengeWords:
-			dc.Challenges[i].Progress += wordsTyped
-			if dc.Challenges[i].Progress >= dc.Challenges[i].Target {
-				dc.Challenges[i].Completed = true
+	return dc.update(func() {
+		for i := range dc.Challenges {
+			if dc.Challenges[i].Date != today {
+				continue
 			}
 
-		case ChallengeNoErrors:
-			if accuracy == 100 && !dc.Challenges[i].Completed {
-				dc.Challenges[i].Progress = 100
-				dc.Challenges[i].Completed = true
+			switch dc.Challenges[i].Type {
+			case ChallengeSpeed:
+				if wpm >= dc.Challenges[i].Target && !dc.Challenges[i].Completed {
+					dc.Challenges[i].Progress = wpm
+					dc.Challenges[i].Completed = true
+				}
+
+			case ChallengeAccuracy:
+				if accuracy >= dc.Challenges[i].Target && !dc.Challenges[i].Completed {
+					dc.Challenges[i].Progress = accuracy
+					dc.Challenges[i].Completed = true
+				}
+
+			case ChallengeWords:
+				dc.Challenges[i].Progress += wordsTyped
+				if dc.Challenges[i].Progress >= dc.Challenges[i].Target {
+					dc.Challenges[i].Completed = true
+				}
+
+			case ChallengeNoErrors:
+				if accuracy == 100 && !dc.Challenges[i].Completed {
+					dc.Challenges[i].Progress = 100
+					dc.Challenges[i].Completed = true
+				}
 			}
 		}
-	}
-
-	return dc.save()
+	})
 }
 
 // GetCompletedCount returns number of completed challenges today
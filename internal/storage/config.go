@@ -1,9 +1,10 @@
 package storage
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+	"fmt"
+	"strconv"
+
+	"ktype/internal/keyboard"
 )
 
 // CursorType represents different cursor styles
@@ -75,25 +76,110 @@ const (
 	ColorCustom
 )
 
+// GhostMode selects what the ghost pacer follows during a test
+type GhostMode int
+
+const (
+	GhostOff  GhostMode = iota
+	GhostPB             // Replay the personal best for the mode
+	GhostPace           // Move at a fixed WPM
+)
+
+// String returns the ghost mode name
+func (g GhostMode) String() string {
+	switch g {
+	case GhostPB:
+		return "pb"
+	case GhostPace:
+		return "pace"
+	default:
+		return "off"
+	}
+}
+
+// ParseGhost parses a ghost setting: "off", "pb" or a target WPM
+func ParseGhost(s string) (GhostMode, int, error) {
+	switch s {
+	case "off", "":
+		return GhostOff, 0, nil
+	case "pb":
+		return GhostPB, 0, nil
+	}
+
+	wpm, err := strconv.Atoi(s)
+	if err != nil || wpm <= 0 || wpm > 300 {
+		return GhostOff, 0, fmt.Errorf("invalid ghost %q (want off, pb or a WPM between 1 and 300)", s)
+	}
+	return GhostPace, wpm, nil
+}
+
+// Strictness controls whether mistakes have to be fixed before the test moves on
+type Strictness int
+
+const (
+	StrictOff    Strictness = iota
+	StrictLetter            // Stop on letter: the cursor waits for the correct character
+	StrictWord              // Stop on word: space is refused until the word matches
+)
+
+// String returns the strictness name
+func (s Strictness) String() string {
+	switch s {
+	case StrictLetter:
+		return "letter"
+	case StrictWord:
+		return "word"
+	default:
+		return "off"
+	}
+}
+
+// ParseStrictness parses a strictness name as returned by Strictness.String
+func ParseStrictness(s string) (Strictness, error) {
+	switch s {
+	case "off", "":
+		return StrictOff, nil
+	case "letter":
+		return StrictLetter, nil
+	case "word":
+		return StrictWord, nil
+	default:
+		return StrictOff, fmt.Errorf("invalid stop mode %q (want off, letter or word)", s)
+	}
+}
+
 // Config holds user preferences
 type Config struct {
+	Version         int         `json:"version"`
 	CursorType      CursorType  `json:"cursor_type"`
 	AccentColor     string      `json:"accent_color"`
 	AccentColorEnum AccentColor `json:"accent_color_enum"`
 	CustomColor     string      `json:"custom_color,omitempty"`
 	ShowHeatmap     bool        `json:"show_heatmap"`
 	SoundEnabled    bool        `json:"sound_enabled"`
+	SaveKeyLogs     bool        `json:"save_keylogs"`
+	Ghost           GhostMode   `json:"ghost"`
+	GhostWPM        int         `json:"ghost_wpm"`
+	Strictness      Strictness  `json:"strictness"`
+	Layout          string      `json:"layout"`  // Keyboard layout name, built in or from layouts.json
+	Emulate         bool        `json:"emulate"` // Type the layout on a QWERTY keymap
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
 	return Config{
+		Version:         configSchema.version(),
 		CursorType:      CursorUnderscore,
 		AccentColor:     "#5eacd3",
 		AccentColorEnum: ColorCyan,
 		CustomColor:     "",
 		ShowHeatmap:     true,
 		SoundEnabled:    false,
+		SaveKeyLogs:     false,
+		Ghost:           GhostOff,
+		GhostWPM:        60,
+		Strictness:      StrictOff,
+		Layout:          keyboard.QWERTY.Name,
 	}
 }
 
@@ -101,52 +187,56 @@ func DefaultConfig() Config {
 type ConfigManager struct {
 	config Config
 	path   string
+
+	// layouts are the user-defined keyboard layouts from layouts.json
+	layouts []keyboard.Layout
 }
 
 // NewConfigManager creates or loads configuration
-func NewConfigManager() *ConfigManager {
+func NewConfigManager(root Root) *ConfigManager {
 	cm := &ConfigManager{
 		config: DefaultConfig(),
-	}
-
-	// Get config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		configDir = os.TempDir()
-	}
-
-	ktypeDir := filepath.Join(configDir, "ktype")
-	if err := os.MkdirAll(ktypeDir, 0755); err != nil {
-		cm.path = "config.json"
-	} else {
-		cm.path = filepath.Join(ktypeDir, "config.json")
+		path:   root.configPath("config.json"),
 	}
 
 	cm.load()
+	cm.loadLayouts(root.configPath("layouts.json"))
 	return cm
 }
 
-// load reads config from file
-func (cm *ConfigManager) load() {
-	data, err := os.ReadFile(cm.path)
+// loadLayouts reads the user-defined keyboard layouts. The file is only ever
+// written by hand, so a mistake in it is reported rather than repaired.
+func (cm *ConfigManager) loadLayouts(path string) {
+	layouts, err := keyboard.Load(path)
 	if err != nil {
-		return // File doesn't exist yet, use defaults
+		addNotice("layouts.json: %v; only the built-in layouts are available", err)
+		return
 	}
+	cm.layouts = layouts
+}
 
-	if err := json.Unmarshal(data, &cm.config); err != nil {
-		// Corrupt file - use defaults
+// load reads config from file
+func (cm *ConfigManager) load() {
+	if err := loadJSON(cm.path, &cm.config, configSchema); err != nil {
+		// Corrupt file without a backup - use defaults
 		cm.config = DefaultConfig()
 	}
 }
 
 // save writes config to file
 func (cm *ConfigManager) save() error {
-	data, err := json.MarshalIndent(cm.config, "", "  ")
-	if err != nil {
-		return err
-	}
+	cm.config.Version = configSchema.version()
+	return saveJSON(cm.path, cm.config)
+}
 
-	return os.WriteFile(cm.path, data, 0644)
+// update applies a change to the config on disk under the file lock, so
+// settings changed in other running instances are kept
+func (cm *ConfigManager) update(apply func()) error {
+	return withLock(cm.path, func() error {
+		cm.load()
+		apply()
+		return cm.save()
+	})
 }
 
 // GetConfig returns the current configuration
@@ -156,52 +246,113 @@ func (cm *ConfigManager) GetConfig() Config {
 
 // SetCursorType updates the cursor type
 func (cm *ConfigManager) SetCursorType(ct CursorType) error {
-	cm.config.CursorType = ct
-	return cm.save()
+	return cm.update(func() {
+		cm.config.CursorType = ct
+	})
 }
 
 // SetAccentColor updates the accent color (string version)
 func (cm *ConfigManager) SetAccentColorString(color string) error {
-	cm.config.AccentColor = color
-	return cm.save()
+	return cm.update(func() {
+		cm.config.AccentColor = color
+	})
 }
 
 // SetAccentColor updates the accent color (enum version)
 func (cm *ConfigManager) SetAccentColor(color AccentColor) {
-	cm.config.AccentColorEnum = color
-	if color == ColorCustom && cm.config.CustomColor != "" {
-		cm.config.AccentColor = cm.config.CustomColor
-	} else {
-		// Set default color for presets
-		colors := []string{
-			"#e06c75", // Red
-			"#d19a66", // Orange
-			"#e5c07b", // Yellow
-			"#98c379", // Green
-			"#56b6c2", // Cyan
-			"#61afef", // Blue
-			"#c678dd", // Purple
-			"#ff79c6", // Pink
-			"#abb2bf", // White
-			"#282c34", // Black
+	cm.update(func() {
+		cm.config.AccentColorEnum = color
+		if color == ColorCustom && cm.config.CustomColor != "" {
+			cm.config.AccentColor = cm.config.CustomColor
+		} else {
+			// Set default color for presets
+			colors := []string{
+				"#e06c75", // Red
+				"#d19a66", // Orange
+				"#e5c07b", // Yellow
+				"#98c379", // Green
+				"#56b6c2", // Cyan
+				"#61afef", // Blue
+				"#c678dd", // Purple
+				"#ff79c6", // Pink
+				"#abb2bf", // White
+				"#282c34", // Black
+			}
+			if int(color) >= 0 && int(color) < len(colors) {
+				cm.config.AccentColor = colors[color]
+			}
 		}
-		if int(color) >= 0 && int(color) < len(colors) {
-			cm.config.AccentColor = colors[color]
-		}
-	}
-	cm.save()
+	})
 }
 
 // SetCustomColor sets a custom hex color, returns true if valid
 func (cm *ConfigManager) SetCustomColor(hex string) bool {
 	if ValidateColor(hex) {
-		cm.config.CustomColor = hex
-		cm.save()
+		cm.update(func() {
+			cm.config.CustomColor = hex
+		})
 		return true
 	}
 	return false
 }
 
+// SetSaveKeyLogs toggles writing keystroke logs to disk after each test
+func (cm *ConfigManager) SetSaveKeyLogs(enabled bool) error {
+	return cm.update(func() {
+		cm.config.SaveKeyLogs = enabled
+	})
+}
+
+// SetGhost updates the ghost pacer mode and its target WPM
+func (cm *ConfigManager) SetGhost(mode GhostMode, wpm int) error {
+	return cm.update(func() {
+		cm.config.Ghost = mode
+		if wpm > 0 {
+			cm.config.GhostWPM = wpm
+		}
+	})
+}
+
+// SetStrictness sets the stop-on-error mode used for new tests
+func (cm *ConfigManager) SetStrictness(s Strictness) error {
+	return cm.update(func() {
+		cm.config.Strictness = s
+	})
+}
+
+// SetEmulate turns layout emulation on or off
+func (cm *ConfigManager) SetEmulate(enabled bool) error {
+	return cm.update(func() {
+		cm.config.Emulate = enabled
+	})
+}
+
+// SetLayout selects the keyboard layout by name
+func (cm *ConfigManager) SetLayout(name string) error {
+	layout, ok := keyboard.Find(name, cm.layouts)
+	if !ok {
+		return fmt.Errorf("unknown keyboard layout %q", name)
+	}
+	return cm.update(func() {
+		cm.config.Layout = layout.Name
+	})
+}
+
+// Layouts returns every keyboard layout that can be selected, built-in
+// layouts first
+func (cm *ConfigManager) Layouts() []keyboard.Layout {
+	return append(keyboard.Builtin(), cm.layouts...)
+}
+
+// GetLayout returns the selected keyboard layout, or QWERTY if the selected
+// one no longer exists
+func (cm *ConfigManager) GetLayout() keyboard.Layout {
+	if layout, ok := keyboard.Find(cm.config.Layout, cm.layouts); ok {
+		return layout
+	}
+	return keyboard.QWERTY
+}
+
 // GetCursorType returns the current cursor type
 func (cm *ConfigManager) GetCursorType() CursorType {
 	return cm.config.CursorType
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseGhost(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  GhostMode
+		wpm   int
+		valid bool
+	}{
+		{"off", GhostOff, 0, true},
+		{"", GhostOff, 0, true},
+		{"pb", GhostPB, 0, true},
+		{"75", GhostPace, 75, true},
+		{"0", GhostOff, 0, false},
+		{"fast", GhostOff, 0, false},
+		{"999", GhostOff, 0, false},
+	}
+
+	for _, tt := range tests {
+		mode, wpm, err := ParseGhost(tt.input)
+		if tt.valid && err != nil {
+			t.Errorf("ParseGhost(%q) returned error: %v", tt.input, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("ParseGhost(%q) should return an error", tt.input)
+		}
+		if mode != tt.mode || wpm != tt.wpm {
+			t.Errorf("ParseGhost(%q) = (%v, %d), expected (%v, %d)", tt.input, mode, wpm, tt.mode, tt.wpm)
+		}
+	}
+}
+
+func TestParseStrictness(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Strictness
+		valid    bool
+	}{
+		{"off", StrictOff, true},
+		{"", StrictOff, true},
+		{"letter", StrictLetter, true},
+		{"word", StrictWord, true},
+		{"char", StrictOff, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStrictness(tt.input)
+		if tt.valid && err != nil {
+			t.Errorf("ParseStrictness(%q) returned error: %v", tt.input, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("ParseStrictness(%q) should return an error", tt.input)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseStrictness(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.SaveKeyLogs {
+		t.Error("Keystroke logs should not be saved by default")
+	}
+
+	if cfg.Ghost != GhostOff {
+		t.Errorf("Expected ghost to be off by default, got %v", cfg.Ghost)
+	}
+
+	if cfg.Strictness != StrictOff {
+		t.Errorf("Expected strict mode to be off by default, got %v", cfg.Strictness)
+	}
+
+	if cfg.Layout != "qwerty" {
+		t.Errorf("Expected the qwerty layout by default, got %q", cfg.Layout)
+	}
+}
+
+func TestConfigLayouts(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	if err := root.ensure(); err != nil {
+		t.Fatal(err)
+	}
+	custom := `{"layouts": [{"name": "Halmak", "rows": ["1234567890", "wlrbz;qudj", "shnt,.aeoi", "fmvc/gpxky"]}]}`
+	if err := os.WriteFile(root.configPath("layouts.json"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := NewConfigManager(root)
+	if got := len(cm.Layouts()); got != 7 {
+		t.Fatalf("Expected 6 built-in layouts and 1 custom, got %d", got)
+	}
+
+	if err := cm.SetLayout("halmak"); err != nil {
+		t.Fatalf("SetLayout failed: %v", err)
+	}
+	if got := NewConfigManager(root).GetLayout().Name; got != "halmak" {
+		t.Errorf("Expected halmak to be saved, got %q", got)
+	}
+
+	if err := cm.SetLayout("nonexistent"); err == nil {
+		t.Error("Expected an error for an unknown layout")
+	}
+
+	// A layout that has since been removed falls back to QWERTY
+	os.Remove(root.configPath("layouts.json"))
+	if got := NewConfigManager(root).GetLayout().Name; got != "qwerty" {
+		t.Errorf("Expected qwerty fallback, got %q", got)
+	}
+}
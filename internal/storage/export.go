@@ -0,0 +1,395 @@
+package storage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is a file format data can be exported to and imported from
+type ExportFormat int
+
+const (
+	FormatCSV ExportFormat = iota
+	FormatJSON
+)
+
+// String returns a string representation of the format
+func (f ExportFormat) String() string {
+	if f == FormatJSON {
+		return "json"
+	}
+	return "csv"
+}
+
+// ParseExportFormat parses a format name as returned by ExportFormat.String
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch s {
+	case "csv":
+		return FormatCSV, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatCSV, fmt.Errorf("unknown format %q (want csv or json)", s)
+	}
+}
+
+// column maps one CSV column to a field of T
+type column[T any] struct {
+	name string
+	get  func(*T) string
+	set  func(*T, string) error
+}
+
+func stringColumn[T any](name string, field func(*T) *string) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return *field(v) },
+		set:  func(v *T, s string) error { *field(v) = s; return nil },
+	}
+}
+
+func intColumn[T any](name string, field func(*T) *int) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return strconv.Itoa(*field(v)) },
+		set: func(v *T, s string) (err error) {
+			*field(v), err = strconv.Atoi(s)
+			return err
+		},
+	}
+}
+
+func floatColumn[T any](name string, field func(*T) *float64) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return strconv.FormatFloat(*field(v), 'f', 2, 64) },
+		set: func(v *T, s string) (err error) {
+			*field(v), err = strconv.ParseFloat(s, 64)
+			return err
+		},
+	}
+}
+
+func boolColumn[T any](name string, field func(*T) *bool) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return strconv.FormatBool(*field(v)) },
+		set: func(v *T, s string) (err error) {
+			*field(v), err = strconv.ParseBool(s)
+			return err
+		},
+	}
+}
+
+// timeColumn keeps full precision, since scores are told apart by their date
+func timeColumn[T any](name string, field func(*T) *time.Time) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return field(v).Format(time.RFC3339Nano) },
+		set: func(v *T, s string) (err error) {
+			*field(v), err = time.Parse(time.RFC3339Nano, s)
+			return err
+		},
+	}
+}
+
+// secondsColumn writes a duration as seconds, which spreadsheets handle better
+func secondsColumn[T any](name string, field func(*T) *time.Duration) column[T] {
+	return column[T]{
+		name: name,
+		get:  func(v *T) string { return strconv.FormatFloat(field(v).Seconds(), 'f', 3, 64) },
+		set: func(v *T, s string) error {
+			seconds, err := strconv.ParseFloat(s, 64)
+			*field(v) = time.Duration(seconds * float64(time.Second))
+			return err
+		},
+	}
+}
+
+var scoreColumns = []column[Score]{
+	timeColumn("date", func(s *Score) *time.Time { return &s.Date }),
+	stringColumn("mode", func(s *Score) *string { return &s.Mode }),
+	intColumn("wpm", func(s *Score) *int { return &s.WPM }),
+	intColumn("raw_wpm", func(s *Score) *int { return &s.RawWPM }),
+	intColumn("accuracy", func(s *Score) *int { return &s.Accuracy }),
+	floatColumn("consistency", func(s *Score) *float64 { return &s.Consistency }),
+	stringColumn("difficulty", func(s *Score) *string { return &s.Difficulty }),
+	stringColumn("complexity", func(s *Score) *string { return &s.Complexity }),
+	stringColumn("word_list", func(s *Score) *string { return &s.WordList }),
+	secondsColumn("duration", func(s *Score) *time.Duration { return &s.Duration }),
+	intColumn("correct_chars", func(s *Score) *int { return &s.CorrectChars }),
+	intColumn("incorrect_chars", func(s *Score) *int { return &s.IncorrectChars }),
+	intColumn("extra_chars", func(s *Score) *int { return &s.ExtraChars }),
+	intColumn("missed_chars", func(s *Score) *int { return &s.MissedChars }),
+}
+
+var keyStatsColumns = []column[KeyStats]{
+	stringColumn("key", func(k *KeyStats) *string { return &k.Key }),
+	intColumn("total_hits", func(k *KeyStats) *int { return &k.TotalHits }),
+	intColumn("error_count", func(k *KeyStats) *int { return &k.ErrorCount }),
+	timeColumn("last_used", func(k *KeyStats) *time.Time { return &k.LastUsed }),
+	secondsColumn("latency", func(k *KeyStats) *time.Duration { return &k.Latency }),
+	intColumn("timed", func(k *KeyStats) *int { return &k.Timed }),
+}
+
+var challengeColumns = []column[Challenge]{
+	stringColumn("id", func(c *Challenge) *string { return &c.ID }),
+	stringColumn("date", func(c *Challenge) *string { return &c.Date }),
+	stringColumn("title", func(c *Challenge) *string { return &c.Title }),
+	stringColumn("description", func(c *Challenge) *string { return &c.Description }),
+	intColumn("type", func(c *Challenge) *int { return (*int)(&c.Type) }),
+	intColumn("target", func(c *Challenge) *int { return &c.Target }),
+	intColumn("progress", func(c *Challenge) *int { return &c.Progress }),
+	boolColumn("completed", func(c *Challenge) *bool { return &c.Completed }),
+	stringColumn("reward", func(c *Challenge) *string { return &c.Reward }),
+}
+
+// ExportScores writes scores in the given format
+func ExportScores(w io.Writer, format ExportFormat, scores []Score) error {
+	return export(w, format, scores, scoreColumns)
+}
+
+// ImportScores reads scores written by ExportScores
+func ImportScores(r io.Reader, format ExportFormat) ([]Score, error) {
+	return importRows(r, format, scoreColumns)
+}
+
+// ExportHeatmap writes per-key statistics in the given format
+func ExportHeatmap(w io.Writer, format ExportFormat, stats []KeyStats) error {
+	return export(w, format, stats, keyStatsColumns)
+}
+
+// ImportHeatmap reads per-key statistics written by ExportHeatmap
+func ImportHeatmap(r io.Reader, format ExportFormat) ([]KeyStats, error) {
+	return importRows(r, format, keyStatsColumns)
+}
+
+// ExportChallenges writes challenges in the given format
+func ExportChallenges(w io.Writer, format ExportFormat, challenges []Challenge) error {
+	return export(w, format, challenges, challengeColumns)
+}
+
+// ImportChallenges reads challenges written by ExportChallenges
+func ImportChallenges(r io.Reader, format ExportFormat) ([]Challenge, error) {
+	return importRows(r, format, challengeColumns)
+}
+
+// export writes rows as a JSON array or as CSV with a header line
+func export[T any](w io.Writer, format ExportFormat, rows []T, columns []column[T]) error {
+	if format == FormatJSON {
+		if rows == nil {
+			rows = []T{}
+		}
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	cw.Write(header)
+
+	record := make([]string, len(columns))
+	for i := range rows {
+		for j, c := range columns {
+			record[j] = c.get(&rows[i])
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// importRows reads rows written by export. CSV columns are matched by the
+// names in the header, so they may be reordered or missing, and unknown
+// columns are ignored.
+func importRows[T any](r io.Reader, format ExportFormat, columns []column[T]) ([]T, error) {
+	if format == FormatJSON {
+		var rows []T
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON export: %w", err)
+		}
+		return rows, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV export: %w", err)
+	}
+
+	byName := make(map[string]column[T], len(columns))
+	for _, c := range columns {
+		byName[c.name] = c
+	}
+
+	var rows []T
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV export: %w", err)
+		}
+
+		var row T
+		for i, value := range record {
+			if i >= len(header) || value == "" {
+				continue
+			}
+			c, ok := byName[header[i]]
+			if !ok {
+				continue
+			}
+			if err := c.set(&row, value); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, c.name, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// scoreKey identifies a score for deduplication: two tests never finish in
+// the same instant in the same mode
+func scoreKey(s Score) string {
+	return s.Date.UTC().Format(time.RFC3339Nano) + " " + s.Mode
+}
+
+// Merge adds scores that aren't in the history yet, matching them by date and
+// mode, and returns how many were added. The history is rewritten in date order.
+func (lb *Leaderboard) Merge(scores []Score) (int, error) {
+	added := 0
+	err := withLock(lb.path, func() error {
+		if err := lb.readNew(); err == errUnrecoverable {
+			lb.recover()
+		}
+
+		seen := make(map[string]bool, len(lb.Scores))
+		for _, s := range lb.Scores {
+			seen[scoreKey(s)] = true
+		}
+		for _, s := range scores {
+			if s.Date.IsZero() || seen[scoreKey(s)] {
+				continue
+			}
+			seen[scoreKey(s)] = true
+			lb.Scores = append(lb.Scores, s)
+			added++
+		}
+		if added == 0 {
+			return nil
+		}
+
+		sort.SliceStable(lb.Scores, func(i, j int) bool {
+			return lb.Scores[i].Date.Before(lb.Scores[j].Date)
+		})
+		lb.pbs = nil
+		return lb.save()
+	})
+	return added, err
+}
+
+// Merge folds imported per-key statistics into the heatmap, keeping for each
+// key whichever record has seen more keystrokes so importing the same export
+// twice changes nothing. It returns the number of keys that changed.
+func (h *Heatmap) Merge(stats []KeyStats) (int, error) {
+	changed := make(map[string]*KeyStats)
+	err := withLock(h.path, func() error {
+		var file heatmapFile
+		if err := loadJSON(h.path, &file, heatmapSchema); err != nil || file.Keys == nil {
+			file.Keys = make(map[string]*KeyStats)
+		}
+
+		for _, s := range stats {
+			if s.Key == "" {
+				continue
+			}
+			if existing, ok := file.Keys[s.Key]; ok && existing.TotalHits >= s.TotalHits {
+				continue
+			}
+			stat := s
+			file.Keys[s.Key] = &stat
+			changed[s.Key] = &stat
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+
+		file.Version = heatmapSchema.version()
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeJSONFile(h.path, data)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	for key, stat := range changed {
+		copied := *stat
+		h.Keys[key] = &copied
+	}
+	h.mu.Unlock()
+	return len(changed), nil
+}
+
+// Merge adds imported challenges by ID. Known challenges are only replaced
+// when the import made more progress on them. It returns how many changed.
+func (dc *DailyChallenges) Merge(challenges []Challenge) (int, error) {
+	changed := 0
+	err := dc.update(func() {
+		index := make(map[string]int, len(dc.Challenges))
+		for i, c := range dc.Challenges {
+			index[c.ID] = i
+		}
+
+		for _, c := range challenges {
+			i, ok := index[c.ID]
+			switch {
+			case c.ID == "":
+				continue
+			case !ok:
+				index[c.ID] = len(dc.Challenges)
+				dc.Challenges = append(dc.Challenges, c)
+			case c.Completed && !dc.Challenges[i].Completed, c.Progress > dc.Challenges[i].Progress:
+				dc.Challenges[i] = c
+			default:
+				continue
+			}
+			changed++
+		}
+	})
+	return changed, err
+}
+
+// Merge adds word lists whose names aren't taken yet and returns how many
+// were added. Lists that already exist are left as they are.
+func (wm *WordListManager) Merge(lists []WordList) (int, error) {
+	added := 0
+	err := wm.update(func() error {
+		for _, list := range lists {
+			if list.Name == "" || wm.GetList(list.Name) != nil {
+				continue
+			}
+			wm.Lists = append(wm.Lists, list)
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
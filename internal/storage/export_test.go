@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testScores() []Score {
+	date := time.Date(2026, 3, 14, 9, 26, 53, 589000000, time.UTC)
+	return []Score{
+		{WPM: 72, RawWPM: 80, Accuracy: 96, Consistency: 81.5, Mode: "time:30",
+			Difficulty: "hard", Complexity: "punctuation", Duration: 30 * time.Second,
+			CorrectChars: 180, IncorrectChars: 4, ExtraChars: 1, MissedChars: 2, Date: date},
+		{WPM: 55, Accuracy: 90, Mode: "words:25", WordList: "go, keywords",
+			Duration: 27500 * time.Millisecond, Date: date.Add(time.Hour)},
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for _, format := range []ExportFormat{FormatCSV, FormatJSON} {
+		got, err := ParseExportFormat(format.String())
+		if err != nil || got != format {
+			t.Errorf("ParseExportFormat(%q) = %v, %v", format.String(), got, err)
+		}
+	}
+	if _, err := ParseExportFormat("xml"); err == nil {
+		t.Error("ParseExportFormat(xml) should fail")
+	}
+}
+
+func TestExportScoresRoundTrip(t *testing.T) {
+	for _, format := range []ExportFormat{FormatCSV, FormatJSON} {
+		var buf bytes.Buffer
+		if err := ExportScores(&buf, format, testScores()); err != nil {
+			t.Fatalf("%v: export: %v", format, err)
+		}
+		scores, err := ImportScores(&buf, format)
+		if err != nil {
+			t.Fatalf("%v: import: %v", format, err)
+		}
+
+		want := testScores()
+		if len(scores) != len(want) {
+			t.Fatalf("%v: got %d scores, want %d", format, len(scores), len(want))
+		}
+		for i := range want {
+			if !scores[i].Date.Equal(want[i].Date) {
+				t.Errorf("%v: score %d date = %v, want %v", format, i, scores[i].Date, want[i].Date)
+			}
+			scores[i].Date = want[i].Date
+			if scores[i] != want[i] {
+				t.Errorf("%v: score %d = %+v, want %+v", format, i, scores[i], want[i])
+			}
+		}
+	}
+}
+
+func TestExportScoresCSVHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportScores(&buf, FormatCSV, nil); err != nil {
+		t.Fatal(err)
+	}
+	header := strings.TrimSpace(buf.String())
+	if !strings.HasPrefix(header, "date,mode,wpm,") {
+		t.Errorf("CSV header = %q", header)
+	}
+}
+
+func TestImportScoresCSVColumnOrder(t *testing.T) {
+	input := "mode,accuracy,wpm,date,unknown\n" +
+		"time:60,97,88,2026-01-02T10:00:00Z,x\n"
+
+	scores, err := ImportScores(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 {
+		t.Fatalf("got %d scores, want 1", len(scores))
+	}
+	s := scores[0]
+	if s.Mode != "time:60" || s.WPM != 88 || s.Accuracy != 97 || s.Date.Year() != 2026 {
+		t.Errorf("imported %+v", s)
+	}
+}
+
+func TestImportScoresInvalid(t *testing.T) {
+	input := "date,mode,wpm\n2026-01-02T10:00:00Z,time:60,fast\n"
+	if _, err := ImportScores(strings.NewReader(input), FormatCSV); err == nil {
+		t.Error("expected an error for a non-numeric wpm")
+	}
+}
+
+func TestLeaderboardMerge(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	lb := NewLeaderboard(root)
+	existing := testScores()[1]
+	lb.AddScore(existing)
+
+	added, err := lb.Merge(testScores())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Errorf("added %d scores, want 1", added)
+	}
+
+	// Importing the same scores again adds nothing
+	if added, _ = lb.Merge(testScores()); added != 0 {
+		t.Errorf("second merge added %d scores, want 0", added)
+	}
+
+	reloaded := NewLeaderboard(root)
+	if reloaded.Count() != 2 {
+		t.Fatalf("reloaded %d scores, want 2", reloaded.Count())
+	}
+	if !reloaded.Scores[0].Date.Before(reloaded.Scores[1].Date) {
+		t.Error("merged history should be in date order")
+	}
+	if pb := reloaded.GetOverallPB(); pb == nil || pb.WPM != 72 {
+		t.Errorf("overall PB = %v, want 72", pb)
+	}
+}
+
+func TestHeatmapMerge(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	h := NewHeatmap(root)
+	defer h.Close()
+	h.RecordHit("a")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := []KeyStats{
+		{Key: "a", TotalHits: 10, ErrorCount: 2},
+		{Key: "b", TotalHits: 5, ErrorCount: 1},
+	}
+	changed, err := h.Merge(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Errorf("changed %d keys, want 2", changed)
+	}
+	if changed, _ = h.Merge(stats); changed != 0 {
+		t.Errorf("second merge changed %d keys, want 0", changed)
+	}
+
+	reloaded := NewHeatmap(root)
+	defer reloaded.Close()
+	got := reloaded.Stats()
+	if len(got) != 2 || got[0].Key != "a" || got[0].TotalHits != 10 || got[1].Key != "b" {
+		t.Errorf("reloaded stats = %+v", got)
+	}
+}
+
+func TestExportHeatmapRoundTrip(t *testing.T) {
+	stats := []KeyStats{
+		{Key: ",", TotalHits: 12, ErrorCount: 3, LastUsed: time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{Key: "\"", TotalHits: 1},
+	}
+	var buf bytes.Buffer
+	if err := ExportHeatmap(&buf, FormatCSV, stats); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ImportHeatmap(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Key != "," || got[0].ErrorCount != 3 ||
+		!got[0].LastUsed.Equal(stats[0].LastUsed) || got[1].Key != "\"" {
+		t.Errorf("round trip = %+v", got)
+	}
+}
+
+func TestDailyChallengesMerge(t *testing.T) {
+	dc := NewDailyChallenges(NewRoot(t.TempDir()))
+	if len(dc.Challenges) == 0 {
+		t.Fatal("expected generated challenges")
+	}
+	done := dc.Challenges[0]
+	done.Completed = true
+	done.Progress = done.Target
+	old := Challenge{ID: "2020-01-01-wpm", Date: "2020-01-01", Title: "Old", Target: 50}
+
+	var buf bytes.Buffer
+	if err := ExportChallenges(&buf, FormatJSON, []Challenge{done, old}); err != nil {
+		t.Fatal(err)
+	}
+	challenges, err := ImportChallenges(&buf, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := dc.Merge(challenges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Errorf("changed %d challenges, want 2", changed)
+	}
+	if !dc.Challenges[0].Completed {
+		t.Error("completed challenge should replace the open one")
+	}
+	if changed, _ = dc.Merge(challenges); changed != 0 {
+		t.Errorf("second merge changed %d challenges, want 0", changed)
+	}
+}
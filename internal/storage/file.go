@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Notices raised while loading data, e.g. when a corrupt file was recovered
+var (
+	noticesMu sync.Mutex
+	notices   []string
+)
+
+// addNotice records a warning to be shown to the user
+func addNotice(format string, args ...any) {
+	noticesMu.Lock()
+	defer noticesMu.Unlock()
+	notices = append(notices, fmt.Sprintf(format, args...))
+}
+
+// TakeNotices returns the warnings raised so far and clears them
+func TakeNotices() []string {
+	noticesMu.Lock()
+	defer noticesMu.Unlock()
+	taken := notices
+	notices = nil
+	return taken
+}
+
+// errUnrecoverable is returned by loadJSON when a file and its backup are both unreadable
+var errUnrecoverable = errors.New("data file and backup are both unreadable")
+
+// backupPath returns the path of the last good copy of a data file
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// loadJSON decodes the JSON file at path into v, first upgrading it to the
+// current version of its schema. Fields missing from the file
+// keep the values v already has, and v is left untouched if decoding fails as
+// long as its maps are nil and its slices empty, which is the case for defaults.
+//
+// A corrupt file is never discarded: it is moved aside and the backup written
+// by saveJSON is loaded in its place, with a notice for the user either way.
+// errUnrecoverable is returned when neither could be read, so the caller can
+// start fresh.
+func loadJSON[T any](path string, v *T, s schema) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil // File doesn't exist yet
+	}
+
+	if decodeJSON(data, v, s) == nil {
+		return nil
+	}
+
+	name := filepath.Base(path)
+	aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, aside); err != nil {
+		aside = path
+	}
+
+	if backup, err := os.ReadFile(backupPath(path)); err == nil && decodeJSON(backup, v, s) == nil {
+		addNotice("%s was damaged and has been restored from its backup (damaged copy kept as %s)", name, filepath.Base(aside))
+		return nil
+	}
+
+	addNotice("%s was damaged and no backup could be read; starting fresh (damaged copy kept as %s)", name, filepath.Base(aside))
+	return errUnrecoverable
+}
+
+// decodeJSON upgrades data and decodes it into a copy of v, only storing it on success
+func decodeJSON[T any](data []byte, v *T, s schema) error {
+	data, err := s.upgrade(data)
+	if err != nil {
+		return err
+	}
+
+	decoded := *v
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*v = decoded
+	return nil
+}
+
+// saveJSON writes v to path as indented JSON
+func saveJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(path, data)
+}
+
+// writeJSONFile atomically replaces path with data, first keeping the current
+// file as a backup if it is still valid JSON. A file with a newer version than
+// data is left alone and errNewerVersion returned.
+func writeJSONFile(path string, data []byte) error {
+	current, err := os.ReadFile(path)
+	switch {
+	case err == nil && json.Valid(current):
+		if fileVersion(current) > fileVersion(data) {
+			return fmt.Errorf("not saving %s: %w", filepath.Base(path), errNewerVersion)
+		}
+		if err := writeFileAtomic(backupPath(path), current, 0644); err != nil {
+			return err
+		}
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	return writeFileAtomic(path, data, 0644)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so a crash mid-write never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	if err := writeFileAtomic(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("First write failed: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("second"), 0644); err != nil {
+		t.Fatalf("Second write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read file: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected 'second', got %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, found %d entries", len(entries))
+	}
+}
+
+func TestSaveJSONKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+
+	saveJSON(path, map[string]int{"v": 1})
+	saveJSON(path, map[string]int{"v": 2})
+
+	var backup map[string]int
+	data, err := os.ReadFile(backupPath(path))
+	if err != nil {
+		t.Fatalf("Expected a backup file: %v", err)
+	}
+	json.Unmarshal(data, &backup)
+	if backup["v"] != 1 {
+		t.Errorf("Expected backup to hold the previous version, got %v", backup)
+	}
+
+	// A damaged file never replaces a good backup
+	os.WriteFile(path, []byte("{broken"), 0644)
+	saveJSON(path, map[string]int{"v": 3})
+	data, _ = os.ReadFile(backupPath(path))
+	json.Unmarshal(data, &backup)
+	if backup["v"] != 1 {
+		t.Errorf("Expected backup to be kept when the current file is damaged, got %v", backup)
+	}
+}
+
+func TestSaveJSONRefusesNewerFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	newer := `{"version": 99, "cursor_type": 2, "added_later": true}`
+	os.WriteFile(path, []byte(newer), 0644)
+
+	cm := &ConfigManager{config: DefaultConfig(), path: path}
+	cm.load()
+	if cm.GetConfig().CursorType != 2 {
+		t.Errorf("Expected the newer file to be read, got %+v", cm.GetConfig())
+	}
+
+	if err := cm.SetCursorType(CursorBlock); !errors.Is(err, errNewerVersion) {
+		t.Errorf("Expected errNewerVersion saving over a newer file, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("Expected the newer file to be left alone, got %s", data)
+	}
+	if _, err := os.Stat(backupPath(path)); !os.IsNotExist(err) {
+		t.Error("Expected no backup to be written")
+	}
+}
+
+func TestLoadJSONRecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wordlists.json")
+	TakeNotices()
+
+	wm := &WordListManager{Lists: []WordList{}, path: path}
+	wm.Lists = append(wm.Lists, WordList{Name: "first", Words: []string{"a"}})
+	wm.save()
+	wm.Lists = append(wm.Lists, WordList{Name: "second", Words: []string{"b"}})
+	wm.save()
+
+	// Simulate a write cut short by a crash of an older version
+	os.WriteFile(path, []byte(`{"lists": [{"name": "fi`), 0644)
+
+	loaded := &WordListManager{Lists: []WordList{}, path: path}
+	loaded.load()
+
+	if len(loaded.Lists) != 1 || loaded.Lists[0].Name != "first" {
+		t.Errorf("Expected the backup's single list, got %+v", loaded.Lists)
+	}
+
+	notices := TakeNotices()
+	if len(notices) != 1 || !strings.Contains(notices[0], "restored") {
+		t.Errorf("Expected a recovery notice, got %v", notices)
+	}
+
+	matches, _ := filepath.Glob(path + ".corrupt-*")
+	if len(matches) != 1 {
+		t.Errorf("Expected the damaged file to be kept aside, found %v", matches)
+	}
+}
+
+func TestLoadJSONWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte("not json"), 0644)
+	TakeNotices()
+
+	cfg := DefaultConfig()
+	if err := loadJSON(path, &cfg, configSchema); err != errUnrecoverable {
+		t.Errorf("Expected errUnrecoverable, got %v", err)
+	}
+	if cfg != DefaultConfig() {
+		t.Error("Expected a failed load to leave the defaults untouched")
+	}
+	if len(TakeNotices()) != 1 {
+		t.Error("Expected a notice about the unrecoverable file")
+	}
+}
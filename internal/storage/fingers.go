@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"time"
+
+	"ktype/internal/keyboard"
+)
+
+// FingerStats aggregates the keystrokes typed by one finger or one hand
+type FingerStats struct {
+	Name    string
+	Hits    int
+	Errors  int
+	Share   float64       // Percentage of the keystrokes assigned to any finger
+	Latency time.Duration // Time to reach the keys, summed over Timed hits
+	Timed   int
+}
+
+// ErrorRate returns the error percentage for this finger
+func (f FingerStats) ErrorRate() float64 {
+	if f.Hits == 0 {
+		return 0.0
+	}
+	return float64(f.Errors) / float64(f.Hits) * 100.0
+}
+
+// AverageLatency returns the mean time this finger takes to reach its keys
+func (f FingerStats) AverageLatency() time.Duration {
+	if f.Timed == 0 {
+		return 0
+	}
+	return f.Latency / time.Duration(f.Timed)
+}
+
+// WPM returns the speed someone would type at if every key took as long as
+// this finger's do on average
+func (f FingerStats) WPM() float64 {
+	latency := f.AverageLatency()
+	if latency == 0 {
+		return 0
+	}
+	return float64(time.Minute) / float64(latency) / 5
+}
+
+// FingerBreakdown groups per-key statistics by the finger and hand that type
+// each key on a layout
+type FingerBreakdown struct {
+	Layout  string
+	Fingers []FingerStats // Left pinky to right pinky
+	Hands   []FingerStats // Left, then right
+
+	// Unassigned counts keystrokes on keys no finger types on the layout,
+	// such as space
+	Unassigned int
+}
+
+// NewFingerBreakdown adds up key statistics by finger and hand on layout
+func NewFingerBreakdown(keys []KeyStats, layout keyboard.Layout) FingerBreakdown {
+	b := FingerBreakdown{
+		Layout:  layout.Name,
+		Fingers: make([]FingerStats, len(keyboard.Fingers)),
+		Hands:   make([]FingerStats, 2),
+	}
+	for _, f := range keyboard.Fingers {
+		b.Fingers[f].Name = f.String()
+	}
+	b.Hands[keyboard.LeftHand].Name = keyboard.LeftHand.String()
+	b.Hands[keyboard.RightHand].Name = keyboard.RightHand.String()
+
+	total := 0
+	for _, k := range keys {
+		finger, ok := layout.Finger(k.Key)
+		if !ok {
+			b.Unassigned += k.TotalHits
+			continue
+		}
+		for _, stats := range []*FingerStats{&b.Fingers[finger], &b.Hands[finger.Hand()]} {
+			stats.Hits += k.TotalHits
+			stats.Errors += k.ErrorCount
+			stats.Latency += k.Latency
+			stats.Timed += k.Timed
+		}
+		total += k.TotalHits
+	}
+
+	if total > 0 {
+		for i := range b.Fingers {
+			b.Fingers[i].Share = float64(b.Fingers[i].Hits) / float64(total) * 100.0
+		}
+		for i := range b.Hands {
+			b.Hands[i].Share = float64(b.Hands[i].Hits) / float64(total) * 100.0
+		}
+	}
+	return b
+}
+
+// GetFingerBreakdown returns the heatmap's statistics grouped by finger and
+// hand on layout
+func (h *Heatmap) GetFingerBreakdown(layout keyboard.Layout) FingerBreakdown {
+	return NewFingerBreakdown(h.Stats(), layout)
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"ktype/internal/keyboard"
+)
+
+func TestNewFingerBreakdown(t *testing.T) {
+	keys := []KeyStats{
+		{Key: "f", TotalHits: 30, ErrorCount: 3, Latency: 3 * time.Second, Timed: 30},
+		{Key: "g", TotalHits: 10, ErrorCount: 1, Latency: 2 * time.Second, Timed: 10},
+		{Key: "j", TotalHits: 40, ErrorCount: 0, Latency: 4 * time.Second, Timed: 40},
+		{Key: "s", TotalHits: 20, ErrorCount: 5},
+		{Key: " ", TotalHits: 25},
+	}
+
+	b := NewFingerBreakdown(keys, keyboard.QWERTY)
+
+	leftIndex := b.Fingers[keyboard.LeftIndex]
+	if leftIndex.Name != "left index" || leftIndex.Hits != 40 || leftIndex.Errors != 4 {
+		t.Errorf("Unexpected left index stats: %+v", leftIndex)
+	}
+	if leftIndex.Share != 40 {
+		t.Errorf("Expected left index share 40%%, got %.1f", leftIndex.Share)
+	}
+	if leftIndex.ErrorRate() != 10 {
+		t.Errorf("Expected left index error rate 10%%, got %.1f", leftIndex.ErrorRate())
+	}
+	// 5s over 40 keys is 125ms a key, 480 keys or 96 words a minute
+	if leftIndex.AverageLatency() != 125*time.Millisecond || leftIndex.WPM() != 96 {
+		t.Errorf("Expected 125ms and 96 wpm, got %v and %.1f", leftIndex.AverageLatency(), leftIndex.WPM())
+	}
+
+	if ring := b.Fingers[keyboard.LeftRing]; ring.ErrorRate() != 25 || ring.WPM() != 0 {
+		t.Errorf("Expected left ring 25%% errors and no timing, got %+v", ring)
+	}
+
+	left, right := b.Hands[keyboard.LeftHand], b.Hands[keyboard.RightHand]
+	if left.Name != "left" || left.Hits != 60 || right.Hits != 40 {
+		t.Errorf("Expected 60 left and 40 right hits, got %+v and %+v", left, right)
+	}
+	if left.Share != 60 || right.Share != 40 {
+		t.Errorf("Expected hand shares 60/40, got %.1f/%.1f", left.Share, right.Share)
+	}
+
+	if b.Unassigned != 25 {
+		t.Errorf("Expected the spaces to be unassigned, got %d", b.Unassigned)
+	}
+
+	// The same keys on Colemak: f and s are both typed by the left middle finger
+	colemak := NewFingerBreakdown(keys, keyboard.Colemak)
+	if colemak.Fingers[keyboard.LeftMiddle].Hits != 50 {
+		t.Errorf("Expected f and s on the Colemak left middle finger, got %+v", colemak.Fingers[keyboard.LeftMiddle])
+	}
+}
+
+func TestStatisticsFingerBreakdown(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	hm := NewHeatmap(root)
+	defer hm.Close()
+	hm.RecordHit("a")
+	hm.RecordTimedHit("l", 100*time.Millisecond)
+	hm.RecordTimedHit("l", 300*time.Millisecond)
+
+	stats := NewStatistics(NewLeaderboard(root))
+	if b := stats.GetFingerBreakdown(); b.Hands[0].Hits+b.Hands[1].Hits != 0 {
+		t.Errorf("Expected an empty breakdown without a heatmap, got %+v", b.Hands)
+	}
+
+	// Filtered analyzers leave the all-time heatmap out
+	stats = stats.WithHeatmap(hm, keyboard.QWERTY)
+	if b := stats.Where(ScoreFilter{Mode: "time:30"}).GetFingerBreakdown(); b.Hands[0].Hits+b.Hands[1].Hits != 0 {
+		t.Errorf("Expected an empty breakdown on filtered scores, got %+v", b.Hands)
+	}
+
+	b := stats.GetFingerBreakdown()
+	if b.Layout != "qwerty" {
+		t.Errorf("Expected the qwerty layout, got %q", b.Layout)
+	}
+	ring := b.Fingers[keyboard.RightRing]
+	if ring.Hits != 2 || ring.AverageLatency() != 200*time.Millisecond {
+		t.Errorf("Expected 2 hits at 200ms on the right ring finger, got %+v", ring)
+	}
+	if pinky := b.Fingers[keyboard.LeftPinky]; pinky.Hits != 1 || pinky.Timed != 0 {
+		t.Errorf("Expected 1 untimed hit on the left pinky, got %+v", pinky)
+	}
+}
@@ -2,11 +2,13 @@ package storage
 
 import (
 	"encoding/json"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"ktype/internal/keyboard"
 )
 
 // KeyStats tracks statistics for individual keys
@@ -15,6 +17,11 @@ type KeyStats struct {
 	TotalHits  int       `json:"total_hits"`
 	ErrorCount int       `json:"error_count"`
 	LastUsed   time.Time `json:"last_used"`
+
+	// Latency is the time since the previous keystroke, summed over the Timed
+	// hits that followed one closely enough to be measured
+	Latency time.Duration `json:"latency,omitempty"`
+	Timed   int           `json:"timed,omitempty"`
 }
 
 // ErrorRate returns the error percentage for this key
@@ -25,78 +32,209 @@ func (k *KeyStats) ErrorRate() float64 {
 	return float64(k.ErrorCount) / float64(k.TotalHits) * 100.0
 }
 
-// Heatmap stores keystroke statistics for all keys
+// AverageLatency returns the mean time taken to reach this key from the
+// previous one
+func (k *KeyStats) AverageLatency() time.Duration {
+	if k.Timed == 0 {
+		return 0
+	}
+	return k.Latency / time.Duration(k.Timed)
+}
+
+// heatmapFile is the on-disk form of a Heatmap
+type heatmapFile struct {
+	Version int                  `json:"version"`
+	Keys    map[string]*KeyStats `json:"keys"`
+}
+
+// HeatmapFlushInterval is how often recorded keystrokes are written to disk
+// in the background, which bounds how much data a crash can lose
+const HeatmapFlushInterval = 5 * time.Second
+
+// Heatmap stores keystroke statistics for all keys. Hits and errors are kept
+// in memory and written to disk by Flush, which runs periodically in the
+// background, when a test ends and on Close.
 type Heatmap struct {
 	Keys map[string]*KeyStats `json:"keys"`
 	path string
+
+	mu      sync.Mutex
+	pending map[string]*KeyStats // Hits and errors recorded since the last flush
+	stop    chan struct{}
+	done    chan struct{}
 }
 
 // NewHeatmap creates or loads a heatmap
-func NewHeatmap() *Heatmap {
+func NewHeatmap(root Root) *Heatmap {
 	h := &Heatmap{
 		Keys: make(map[string]*KeyStats),
+		path: root.dataPath("heatmap.json"),
 	}
 
-	// Get config directory
-	configDir, err := os.UserConfigDir()
+	h.load()
+	h.startAutoFlush(HeatmapFlushInterval)
+	return h
+}
+
+// startAutoFlush flushes pending keystrokes every interval until Close is called
+func (h *Heatmap) startAutoFlush(interval time.Duration) {
+	h.stop = make(chan struct{})
+	h.done = make(chan struct{})
+
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.Flush()
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Flush adds the keystrokes recorded since the last flush to the heatmap on
+// disk. It merges into whatever is there under the file lock rather than
+// overwriting it, so instances running side by side don't lose each other's data.
+func (h *Heatmap) Flush() error {
+	h.mu.Lock()
+	pending := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := withLock(h.path, func() error {
+		var file heatmapFile
+		if err := loadJSON(h.path, &file, heatmapSchema); err != nil || file.Keys == nil {
+			file.Keys = make(map[string]*KeyStats)
+		}
+		mergeKeyStats(file.Keys, pending)
+		file.Version = heatmapSchema.version()
+
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeJSONFile(h.path, data)
+	})
 	if err != nil {
-		configDir = os.TempDir()
+		// Keep the keystrokes for the next attempt
+		h.mu.Lock()
+		if h.pending == nil {
+			h.pending = make(map[string]*KeyStats)
+		}
+		mergeKeyStats(h.pending, pending)
+		h.mu.Unlock()
 	}
+	return err
+}
 
-	ktypeDir := filepath.Join(configDir, "ktype")
-	if err := os.MkdirAll(ktypeDir, 0755); err != nil {
-		h.path = "heatmap.json"
-	} else {
-		h.path = filepath.Join(ktypeDir, "heatmap.json")
+// mergeKeyStats adds the counts in from to those in into
+func mergeKeyStats(into, from map[string]*KeyStats) {
+	for key, delta := range from {
+		stats, exists := into[key]
+		if !exists {
+			stats = &KeyStats{Key: key}
+			into[key] = stats
+		}
+		stats.TotalHits += delta.TotalHits
+		stats.ErrorCount += delta.ErrorCount
+		stats.Latency += delta.Latency
+		stats.Timed += delta.Timed
+		if delta.LastUsed.After(stats.LastUsed) {
+			stats.LastUsed = delta.LastUsed
+		}
 	}
+}
 
-	h.load()
-	return h
+// Close stops the background flushing and writes any pending keystrokes
+func (h *Heatmap) Close() error {
+	if h.stop != nil {
+		close(h.stop)
+		<-h.done
+		h.stop = nil
+	}
+	return h.Flush()
 }
 
 // load reads heatmap from file
 func (h *Heatmap) load() {
-	data, err := os.ReadFile(h.path)
-	if err != nil {
-		return // File doesn't exist yet
-	}
-
-	if err := json.Unmarshal(data, h); err != nil {
-		// Corrupt file - start fresh
+	var file heatmapFile
+	if err := loadJSON(h.path, &file, heatmapSchema); err != nil {
+		// Corrupt file without a backup - start fresh
 		h.Keys = make(map[string]*KeyStats)
+		return
+	}
+	if file.Keys != nil {
+		h.Keys = file.Keys
 	}
 }
 
-// save writes heatmap to file
+// save replaces the heatmap on disk with the one in memory, dropping anything
+// waiting to be flushed
 func (h *Heatmap) save() error {
-	data, err := json.MarshalIndent(h, "", "  ")
-	if err != nil {
-		return err
-	}
+	return withLock(h.path, func() error {
+		h.mu.Lock()
+		data, err := json.MarshalIndent(heatmapFile{Version: heatmapSchema.version(), Keys: h.Keys}, "", "  ")
+		h.pending = nil
+		h.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return writeJSONFile(h.path, data)
+	})
+}
 
-	return os.WriteFile(h.path, data, 0644)
+// record counts a keystroke for key, both in memory and in the pending
+// changes for the next flush. A positive latency is added to the key's
+// timing. The caller must hold h.mu.
+func (h *Heatmap) record(key string, hits, errors int, latency time.Duration) {
+	now := time.Now()
+	if h.pending == nil {
+		h.pending = make(map[string]*KeyStats)
+	}
+	for _, keys := range []map[string]*KeyStats{h.Keys, h.pending} {
+		if _, exists := keys[key]; !exists {
+			keys[key] = &KeyStats{Key: key}
+		}
+		keys[key].TotalHits += hits
+		keys[key].ErrorCount += errors
+		keys[key].LastUsed = now
+		if latency > 0 {
+			keys[key].Latency += latency
+			keys[key].Timed++
+		}
+	}
 }
 
 // RecordHit records a successful keystroke
 func (h *Heatmap) RecordHit(key string) {
+	h.RecordTimedHit(key, 0)
+}
+
+// RecordTimedHit records a keystroke typed latency after the previous one.
+// A latency of zero means the keystroke couldn't be timed, e.g. because it
+// was the first of a test.
+func (h *Heatmap) RecordTimedHit(key string, latency time.Duration) {
 	if key == "" {
 		return
 	}
 
 	// Normalize key
 	key = strings.ToLower(key)
-	if len(key) > 1 {
+	if utf8.RuneCountInString(key) > 1 {
 		// Handle special keys
 		key = normalizeKey(key)
 	}
 
-	if _, exists := h.Keys[key]; !exists {
-		h.Keys[key] = &KeyStats{Key: key}
-	}
-
-	h.Keys[key].TotalHits++
-	h.Keys[key].LastUsed = time.Now()
-	h.save()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.record(key, 1, 0, latency)
 }
 
 // RecordError records an error for a key
@@ -107,17 +245,13 @@ func (h *Heatmap) RecordError(key string) {
 
 	// Normalize key
 	key = strings.ToLower(key)
-	if len(key) > 1 {
+	if utf8.RuneCountInString(key) > 1 {
 		key = normalizeKey(key)
 	}
 
-	if _, exists := h.Keys[key]; !exists {
-		h.Keys[key] = &KeyStats{Key: key}
-	}
-
-	h.Keys[key].ErrorCount++
-	h.Keys[key].LastUsed = time.Now()
-	h.save()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.record(key, 0, 1, 0)
 }
 
 // normalizeKey converts special key names to standard format
@@ -136,19 +270,40 @@ func normalizeKey(key string) string {
 		return "esc"
 	default:
 		// For other multi-character keys, just return first char or the key itself
-		if len(key) == 1 {
+		r, size := utf8.DecodeRuneInString(key)
+		if r == utf8.RuneError {
 			return key
 		}
-		return key[:1]
+		return key[:size]
+	}
+}
+
+// Stats returns a copy of the statistics of every key, sorted by key
+func (h *Heatmap) Stats() []KeyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make([]KeyStats, 0, len(h.Keys))
+	for _, stat := range h.Keys {
+		stats = append(stats, *stat)
 	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+	return stats
 }
 
-// GetTopErrors returns keys with the most errors, sorted by error rate
+// GetTopErrors returns copies of the keys with the most errors, sorted by
+// error rate
 func (h *Heatmap) GetTopErrors(limit int) []*KeyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	var stats []*KeyStats
 	for _, stat := range h.Keys {
 		if stat.ErrorCount > 0 {
-			stats = append(stats, stat)
+			stat := *stat
+			stats = append(stats, &stat)
 		}
 	}
 
@@ -164,11 +319,15 @@ func (h *Heatmap) GetTopErrors(limit int) []*KeyStats {
 	return stats
 }
 
-// GetMostUsed returns the most frequently typed keys
+// GetMostUsed returns copies of the most frequently typed keys
 func (h *Heatmap) GetMostUsed(limit int) []*KeyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	var stats []*KeyStats
 	for _, stat := range h.Keys {
-		stats = append(stats, stat)
+		stat := *stat
+		stats = append(stats, &stat)
 	}
 
 	// Sort by total hits (descending)
@@ -183,23 +342,28 @@ func (h *Heatmap) GetMostUsed(limit int) []*KeyStats {
 	return stats
 }
 
-// GetHeatmapData returns heatmap data organized by keyboard rows
-func (h *Heatmap) GetHeatmapData() KeyboardHeatmap {
+// GetHeatmapData returns heatmap data organized by the rows of a keyboard layout
+func (h *Heatmap) GetHeatmapData(layout keyboard.Layout) KeyboardHeatmap {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	return KeyboardHeatmap{
-		TopRow:    h.getRowStats("qwertyuiop"),
-		HomeRow:   h.getRowStats("asdfghjkl;"),
-		BottomRow: h.getRowStats("zxcvbnm,./"),
-		Numbers:   h.getRowStats("1234567890"),
+		Layout:    layout.Name,
+		TopRow:    h.getRowStats(layout.Row(keyboard.RowTop)),
+		HomeRow:   h.getRowStats(layout.Row(keyboard.RowHome)),
+		BottomRow: h.getRowStats(layout.Row(keyboard.RowBottom)),
+		Numbers:   h.getRowStats(layout.Row(keyboard.RowNumbers)),
 	}
 }
 
-// getRowStats gets stats for a specific row of keys
-func (h *Heatmap) getRowStats(keys string) []*KeyStats {
+// getRowStats gets copies of the stats for a specific row of keys. The
+// caller must hold h.mu.
+func (h *Heatmap) getRowStats(keys []string) []*KeyStats {
 	var stats []*KeyStats
-	for _, key := range keys {
-		keyStr := string(key)
+	for _, keyStr := range keys {
 		if stat, exists := h.Keys[keyStr]; exists {
-			stats = append(stats, stat)
+			stat := *stat
+			stats = append(stats, &stat)
 		} else {
 			// Return empty stat for keys not yet typed
 			stats = append(stats, &KeyStats{Key: keyStr})
@@ -210,6 +374,7 @@ func (h *Heatmap) getRowStats(keys string) []*KeyStats {
 
 // KeyboardHeatmap organizes heatmap data by keyboard rows
 type KeyboardHeatmap struct {
+	Layout    string // Name of the layout the rows follow
 	TopRow    []*KeyStats
 	HomeRow   []*KeyStats
 	BottomRow []*KeyStats
@@ -249,6 +414,9 @@ func GetHeatColor(level int) string {
 
 // GetTotalKeystrokes returns total keystrokes recorded
 func (h *Heatmap) GetTotalKeystrokes() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	total := 0
 	for _, stat := range h.Keys {
 		total += stat.TotalHits
@@ -258,6 +426,9 @@ func (h *Heatmap) GetTotalKeystrokes() int {
 
 // GetTotalErrors returns total errors recorded
 func (h *Heatmap) GetTotalErrors() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	total := 0
 	for _, stat := range h.Keys {
 		total += stat.ErrorCount
@@ -279,6 +450,8 @@ func (h *Heatmap) GetOverallAccuracy() float64 {
 
 // Clear resets all heatmap data
 func (h *Heatmap) Clear() {
+	h.mu.Lock()
 	h.Keys = make(map[string]*KeyStats)
+	h.mu.Unlock()
 	h.save()
 }
@@ -5,10 +5,12 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"ktype/internal/keyboard"
 )
 
 func TestNewHeatmap(t *testing.T) {
-	hm := NewHeatmap()
+	hm := NewHeatmap(NewRoot(t.TempDir()))
 	if hm == nil {
 		t.Fatal("NewHeatmap returned nil")
 	}
@@ -90,6 +92,7 @@ func TestNormalizeKey(t *testing.T) {
 		{"tab", "⇥"},
 		{"esc", "esc"},
 		{"abc", "a"},
+		{"ürün", "ü"},
 	}
 
 	for _, tt := range tests {
@@ -148,6 +151,33 @@ func TestGetMostUsed(t *testing.T) {
 	}
 }
 
+func TestHeatmapReadsWhileRecording(t *testing.T) {
+	hm := &Heatmap{Keys: make(map[string]*KeyStats), path: filepath.Join(t.TempDir(), "heatmap.json")}
+	hm.RecordError("a")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			hm.RecordHit("a")
+			hm.RecordError("b")
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		hm.GetTopErrors(5)
+		hm.GetMostUsed(5)
+		hm.GetHeatmapData(keyboard.QWERTY)
+		hm.GetOverallAccuracy()
+	}
+	<-done
+
+	// The results are copies, so callers can't change the heatmap
+	hm.GetMostUsed(1)[0].TotalHits = 0
+	if hm.GetTotalKeystrokes() != 200 {
+		t.Errorf("Expected 200 keystrokes, got %d", hm.GetTotalKeystrokes())
+	}
+}
+
 func TestGetHeatmapData(t *testing.T) {
 	hm := &Heatmap{
 		Keys: map[string]*KeyStats{
@@ -159,7 +189,7 @@ func TestGetHeatmapData(t *testing.T) {
 		path: filepath.Join(os.TempDir(), "test_heatmap.json"),
 	}
 
-	data := hm.GetHeatmapData()
+	data := hm.GetHeatmapData(keyboard.QWERTY)
 
 	// Check that we have the right number of keys in each row
 	if len(data.TopRow) != 10 {
@@ -318,3 +348,150 @@ func TestHeatmapSaveAndLoad(t *testing.T) {
 		t.Errorf("Expected 'a' to have 10 hits after load, got %d", hm2.Keys["a"].TotalHits)
 	}
 }
+
+func TestHeatmapFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heatmap.json")
+	hm := &Heatmap{
+		Keys: make(map[string]*KeyStats),
+		path: path,
+	}
+
+	hm.RecordHit("a")
+	hm.RecordError("a")
+
+	// Keystrokes are batched in memory, not written one by one
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("Expected nothing on disk before Flush")
+	}
+
+	if err := hm.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	loaded := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+	loaded.load()
+	if loaded.Keys["a"] == nil || loaded.Keys["a"].TotalHits != 1 || loaded.Keys["a"].ErrorCount != 1 {
+		t.Errorf("Expected flushed stats for 'a', got %+v", loaded.Keys["a"])
+	}
+
+	// A flush without new keystrokes leaves the file alone
+	os.Remove(path)
+	hm.Flush()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected Flush to skip writing when nothing changed")
+	}
+}
+
+func TestHeatmapAutoFlushAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heatmap.json")
+	hm := &Heatmap{
+		Keys: make(map[string]*KeyStats),
+		path: path,
+	}
+	hm.startAutoFlush(10 * time.Millisecond)
+
+	hm.RecordHit("a")
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the background flush to write the heatmap")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	hm.RecordHit("b")
+	if err := hm.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	loaded := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+	loaded.load()
+	if loaded.Keys["b"] == nil {
+		t.Error("Expected Close to flush pending keystrokes")
+	}
+}
+
+func TestHeatmapFlushMergesInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heatmap.json")
+	hm1 := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+	hm2 := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+
+	hm1.RecordHit("a")
+	hm1.RecordHit("a")
+	hm2.RecordHit("a")
+	hm2.RecordError("b")
+
+	if err := hm1.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := hm2.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	loaded := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+	loaded.load()
+	if loaded.Keys["a"] == nil || loaded.Keys["a"].TotalHits != 3 {
+		t.Errorf("Expected hits from both instances for 'a', got %+v", loaded.Keys["a"])
+	}
+	if loaded.Keys["b"] == nil || loaded.Keys["b"].ErrorCount != 1 {
+		t.Errorf("Expected the error for 'b' to be kept, got %+v", loaded.Keys["b"])
+	}
+
+	// Flushing again must not count the same keystrokes twice
+	hm1.Flush()
+	loaded.load()
+	if loaded.Keys["a"].TotalHits != 3 {
+		t.Errorf("Expected 3 hits after a second flush, got %d", loaded.Keys["a"].TotalHits)
+	}
+}
+
+func TestGetHeatmapDataLayout(t *testing.T) {
+	hm := &Heatmap{
+		Keys: map[string]*KeyStats{
+			"r": {Key: "r", TotalHits: 4, ErrorCount: 1},
+		},
+	}
+
+	data := hm.GetHeatmapData(keyboard.Colemak)
+	if data.Layout != "colemak" {
+		t.Errorf("Expected colemak layout, got %q", data.Layout)
+	}
+	// On Colemak r sits on the home row, under the left ring finger
+	if data.HomeRow[1].Key != "r" || data.HomeRow[1].TotalHits != 4 {
+		t.Errorf("Expected r second on the home row, got %+v", data.HomeRow[1])
+	}
+	for _, stat := range data.TopRow {
+		if stat.Key == "r" {
+			t.Error("r should not be on the Colemak top row")
+		}
+	}
+}
+
+func TestHeatmapRecordTimedHit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heatmap.json")
+	hm := &Heatmap{
+		Keys: make(map[string]*KeyStats),
+		path: path,
+	}
+
+	hm.RecordTimedHit("E", 150*time.Millisecond)
+	hm.RecordTimedHit("e", 250*time.Millisecond)
+	hm.RecordHit("e")
+
+	stat := hm.Keys["e"]
+	if stat.TotalHits != 3 || stat.Timed != 2 || stat.AverageLatency() != 200*time.Millisecond {
+		t.Errorf("Expected 3 hits, 2 timed at 200ms, got %+v", stat)
+	}
+
+	if err := hm.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	loaded := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+	loaded.load()
+	if got := loaded.Keys["e"]; got == nil || got.Latency != 400*time.Millisecond || got.Timed != 2 {
+		t.Errorf("Expected the timing to be flushed, got %+v", got)
+	}
+}
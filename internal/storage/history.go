@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The score history is a JSON Lines file: a header line naming the format and
+// its version, then one score per line, oldest first. New scores are appended
+// so adding a test costs one short write no matter how long the history is.
+
+// historyFormat identifies the header line of a history file
+const historyFormat = "ktype-history"
+
+// historyHeader is the first line of a history file
+type historyHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// load reads the whole history from disk, first converting the scores.json
+// of earlier versions if there is no history yet. It holds the file lock so
+// a score another instance is appending is either all there or not at all.
+func (lb *Leaderboard) load() {
+	read := func() error {
+		lb.Scores, lb.pbs, lb.read, lb.file, lb.version = []Score{}, nil, 0, nil, 0
+
+		if _, err := os.Stat(lb.path); os.IsNotExist(err) && lb.legacyPath != "" {
+			lb.importLegacy()
+			return nil
+		}
+
+		if err := lb.readNew(); err == errUnrecoverable {
+			lb.recover()
+		}
+		return nil
+	}
+
+	if err := withLock(lb.path, read); err != nil {
+		// No lock to be had (e.g. the directory doesn't exist yet), so read
+		// what is there regardless
+		read()
+	}
+}
+
+// readNew appends the scores added to the history file since it was last read.
+// If the file was replaced in the meantime it is read again from the start.
+// The caller must hold the file lock.
+func (lb *Leaderboard) readNew() error {
+	f, err := os.Open(lb.path)
+	if err != nil {
+		return nil // File doesn't exist yet
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if lb.file != nil && (!os.SameFile(lb.file, info) || info.Size() < lb.read) {
+		lb.Scores, lb.pbs, lb.read, lb.version = []Score{}, nil, 0, 0
+	}
+	lb.file = info
+
+	if _, err := f.Seek(lb.read, io.SeekStart); err != nil {
+		return err
+	}
+
+	damaged := 0
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// A last line without its newline was cut short by a crash. It is
+			// left unread: the next append starts a fresh line after it, and
+			// it is then counted as damaged.
+			break
+		}
+		lb.read += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if lb.version == 0 {
+			var header historyHeader
+			if json.Unmarshal(line, &header) != nil || header.Format != historyFormat {
+				return errUnrecoverable
+			}
+			lb.version = header.Version
+			if lb.version > historySchema.version() {
+				addNotice("%s is from a newer version of ktype (v%d, this one knows v%d); it is read-only, so new scores won't be saved", filepath.Base(lb.path), lb.version, historySchema.version())
+			}
+			continue
+		}
+
+		score, err := decodeHistoryEntry(line, lb.version)
+		if err != nil {
+			damaged++
+			continue
+		}
+		lb.Scores = append(lb.Scores, score)
+		lb.indexScore(len(lb.Scores) - 1)
+	}
+
+	if damaged > 0 {
+		addNotice("%d damaged entries in %s were skipped", damaged, filepath.Base(lb.path))
+	}
+	return nil
+}
+
+// decodeHistoryEntry decodes one line of a history file written at version
+func decodeHistoryEntry(line []byte, version int) (Score, error) {
+	var score Score
+	if version < historySchema.version() {
+		var doc map[string]any
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return score, err
+		}
+		if err := historySchema.migrate(doc, version); err != nil {
+			return score, err
+		}
+		var err error
+		if line, err = json.Marshal(doc); err != nil {
+			return score, err
+		}
+	}
+	err := json.Unmarshal(line, &score)
+	return score, err
+}
+
+// recover handles a history file whose header can't be read: it is moved
+// aside and the backup kept by save is read instead, if there is one
+func (lb *Leaderboard) recover() {
+	name := filepath.Base(lb.path)
+	aside := fmt.Sprintf("%s.corrupt-%s", lb.path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(lb.path, aside); err != nil {
+		aside = lb.path
+	}
+
+	lb.Scores, lb.pbs, lb.read, lb.file, lb.version = []Score{}, nil, 0, nil, 0
+	if data, err := os.ReadFile(backupPath(lb.path)); err == nil {
+		if writeFileAtomic(lb.path, data, 0644) == nil && lb.readNew() == nil {
+			addNotice("%s was damaged and has been restored from its backup (damaged copy kept as %s)", name, filepath.Base(aside))
+			return
+		}
+	}
+
+	lb.Scores, lb.pbs, lb.read, lb.file, lb.version = []Score{}, nil, 0, nil, 0
+	addNotice("%s was damaged and no backup could be read; starting fresh (damaged copy kept as %s)", name, filepath.Base(aside))
+}
+
+// importLegacy converts the scores.json of earlier versions into a history
+// file. The old file is kept as scores.json.migrated.
+func (lb *Leaderboard) importLegacy() {
+	if _, err := os.Stat(lb.legacyPath); err != nil {
+		return
+	}
+
+	var legacy struct {
+		Version int     `json:"version"`
+		Scores  []Score `json:"scores"`
+	}
+	if err := loadJSON(lb.legacyPath, &legacy, scoresSchema); err != nil {
+		return
+	}
+
+	lb.Scores = legacy.Scores
+	if err := lb.save(); err != nil {
+		lb.Scores = []Score{}
+		return
+	}
+	os.Rename(lb.legacyPath, lb.legacyPath+".migrated")
+
+	// Read the converted file back so the PB index is built from it
+	lb.Scores, lb.pbs, lb.read, lb.file, lb.version = []Score{}, nil, 0, nil, 0
+	if err := lb.readNew(); err == errUnrecoverable {
+		lb.recover()
+	}
+}
+
+// appendScore adds a score to the end of the history file. The caller must
+// hold the file lock and have read the file up to its end.
+func (lb *Leaderboard) appendScore(score Score) error {
+	if lb.version > historySchema.version() {
+		return fmt.Errorf("not saving %s: %w", filepath.Base(lb.path), errNewerVersion)
+	}
+
+	entry, err := json.Marshal(score)
+	if err != nil {
+		return err
+	}
+
+	// New entries are in the current format, which an older file's header
+	// would misdescribe, so such a file is converted first
+	if lb.version != 0 && lb.version < historySchema.version() {
+		if err := lb.save(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(lb.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		lb.version = historySchema.version()
+		header, _ := json.Marshal(historyHeader{Format: historyFormat, Version: lb.version})
+		buf.Write(header)
+		buf.WriteByte('\n')
+	} else {
+		// If the last line was cut short, start ours on a fresh line
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	buf.Write(entry)
+	buf.WriteByte('\n')
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	lb.read = info.Size() + int64(buf.Len())
+	if lb.file, err = f.Stat(); err != nil {
+		lb.file = nil
+	}
+	return nil
+}
+
+// save rewrites the whole history file in the current format, keeping the
+// previous one as a backup. Adding a score never needs this; it is used when
+// converting or importing history. A file from a newer ktype is left alone.
+func (lb *Leaderboard) save() error {
+	if lb.version > historySchema.version() {
+		return fmt.Errorf("not saving %s: %w", filepath.Base(lb.path), errNewerVersion)
+	}
+
+	var buf bytes.Buffer
+	header, _ := json.Marshal(historyHeader{Format: historyFormat, Version: historySchema.version()})
+	buf.Write(header)
+	buf.WriteByte('\n')
+	for _, score := range lb.Scores {
+		entry, err := json.Marshal(score)
+		if err != nil {
+			return err
+		}
+		buf.Write(entry)
+		buf.WriteByte('\n')
+	}
+
+	if current, err := os.ReadFile(lb.path); err == nil && bytes.HasPrefix(current, []byte("{\"format\":\""+historyFormat)) {
+		if err := writeFileAtomic(backupPath(lb.path), current, 0644); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(lb.path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	lb.version = historySchema.version()
+	lb.read = int64(buf.Len())
+	lb.file, _ = os.Stat(lb.path)
+	return nil
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KeyLogDir returns the directory keystroke logs are stored in. It is only
+// created once a log is written, see WriteKeyLog.
+func (r Root) KeyLogDir() string {
+	return r.dataPath("keylogs")
+}
+
+// KeyLogID returns the ID of the keystroke log for a test finished at t.
+// Logs are saved under the date of their score so they can be found from the leaderboard.
+func KeyLogID(t time.Time) string {
+	return t.UTC().Format("20060102-150405.000")
+}
+
+// HasKeyLog returns true if a keystroke log was saved for the score
+func (r Root) HasKeyLog(score Score) bool {
+	_, err := os.Stat(r.KeyLogPath(KeyLogID(score.Date)))
+	return err == nil
+}
+
+// WriteKeyLog writes an encoded keystroke log to path atomically, so a crash
+// never leaves a truncated log for the replay viewer
+func WriteKeyLog(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// KeyLogPath returns the file path of the keystroke log with the given ID
+func (r Root) KeyLogPath(id string) string {
+	return filepath.Join(r.KeyLogDir(), id+".json")
+}
@@ -1,102 +1,148 @@
 package storage
 
 import (
-	"encoding/json"
 	"os"
-	"path/filepath"
 	"sort"
 	"time"
 )
 
 // Score represents a single test result
 type Score struct {
-	WPM      int       `json:"wpm"`
-	Accuracy int       `json:"accuracy"`
-	Mode     string    `json:"mode"` // "time:30", "time:60", "words:25", etc.
-	Date     time.Time `json:"date"`
+	WPM         int     `json:"wpm"`
+	RawWPM      int     `json:"raw_wpm,omitempty"`
+	Accuracy    int     `json:"accuracy"`
+	Consistency float64 `json:"consistency,omitempty"`
+	Mode        string  `json:"mode"` // "time:30", "time:60", "words:25", etc.
+
+	// Settings of the test. Difficulty is empty when the words didn't come
+	// from the built-in generator, and WordList names the custom list used.
+	Difficulty string `json:"difficulty,omitempty"`
+	Complexity string `json:"complexity,omitempty"`
+	WordList   string `json:"word_list,omitempty"`
+
+	Duration       time.Duration `json:"duration,omitempty"` // Time spent typing
+	CorrectChars   int           `json:"correct_chars,omitempty"`
+	IncorrectChars int           `json:"incorrect_chars,omitempty"` // Wrong or swapped characters left in
+	ExtraChars     int           `json:"extra_chars,omitempty"`
+	MissedChars    int           `json:"missed_chars,omitempty"`
+
+	Date time.Time `json:"date"`
 }
 
-// Leaderboard manages local scores
+// ScoreFilter selects scores by the settings they were set with and when.
+// Empty fields match every score.
+type ScoreFilter struct {
+	Mode       string
+	Difficulty string
+	Complexity string
+	WordList   string
+
+	Since time.Time // Only scores from this time on
+	Until time.Time // Only scores from before this time
+}
+
+// Matches reports whether a score passes the filter
+func (f ScoreFilter) Matches(score Score) bool {
+	return (f.Mode == "" || score.Mode == f.Mode) &&
+		(f.Difficulty == "" || score.Difficulty == f.Difficulty) &&
+		(f.Complexity == "" || score.Complexity == f.Complexity) &&
+		(f.WordList == "" || score.WordList == f.WordList) &&
+		(f.Since.IsZero() || !score.Date.Before(f.Since)) &&
+		(f.Until.IsZero() || score.Date.Before(f.Until))
+}
+
+// Leaderboard manages local scores. All scores are kept in memory, oldest
+// first, and stored in an append-only history file (see history.go).
 type Leaderboard struct {
-	Scores []Score `json:"scores"`
+	Scores []Score
 	path   string
+
+	// legacyPath is the scores.json of earlier versions, converted on first load
+	legacyPath string
+
+	read    int64       // Bytes of the history file already in Scores
+	file    os.FileInfo // History file read from, to notice when it is replaced
+	version int         // Version of the history file's entries
+	pbs     map[string]int
 }
 
 // NewLeaderboard creates or loads a leaderboard
-func NewLeaderboard() *Leaderboard {
+func NewLeaderboard(root Root) *Leaderboard {
 	lb := &Leaderboard{
-		Scores: []Score{},
-	}
-
-	// Get config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		configDir = os.TempDir()
-	}
-
-	ktypeDir := filepath.Join(configDir, "ktype")
-	if err := os.MkdirAll(ktypeDir, 0755); err != nil {
-		// Fall back to current directory
-		lb.path = "scores.json"
-	} else {
-		lb.path = filepath.Join(ktypeDir, "scores.json")
+		Scores:     []Score{},
+		path:       root.dataPath("history.jsonl"),
+		legacyPath: root.dataPath("scores.json"),
 	}
 
 	lb.load()
 	return lb
 }
 
-// load reads scores from file
-func (lb *Leaderboard) load() {
-	data, err := os.ReadFile(lb.path)
-	if err != nil {
-		return // File doesn't exist yet
+// AddScore adds a test result and saves, returning the stored score.
+// The date is set to now unless the score already has one. Scores other
+// running instances added in the meantime are picked up first. If the score
+// can't be written it is still kept for this session and the error returned.
+func (lb *Leaderboard) AddScore(score Score) (Score, error) {
+	if score.Date.IsZero() {
+		score.Date = time.Now()
 	}
 
-	if err := json.Unmarshal(data, lb); err != nil {
-		// Corrupt file - start fresh
-		lb.Scores = []Score{}
-	}
+	err := withLock(lb.path, func() error {
+		if err := lb.readNew(); err == errUnrecoverable {
+			lb.recover()
+		}
+		return lb.appendScore(score)
+	})
+	lb.Scores = append(lb.Scores, score)
+	lb.indexScore(len(lb.Scores) - 1)
+	return score, err
 }
 
-// save writes scores to file
-func (lb *Leaderboard) save() error {
-	data, err := json.MarshalIndent(lb, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(lb.path, data, 0644)
+// Count returns the number of scores in the history
+func (lb *Leaderboard) Count() int {
+	return len(lb.Scores)
 }
 
-// AddScore adds a new score and saves
-func (lb *Leaderboard) AddScore(wpm, accuracy int, mode string) {
-	score := Score{
-		WPM:      wpm,
-		Accuracy: accuracy,
-		Mode:     mode,
-		Date:     time.Now(),
+// Page returns one page of the history, newest first. Page 0 holds the
+// most recent size scores.
+func (lb *Leaderboard) Page(page, size int) []Score {
+	var scores []Score
+	for i := len(lb.Scores) - 1 - page*size; i >= 0 && len(scores) < size; i-- {
+		scores = append(scores, lb.Scores[i])
 	}
+	return scores
+}
 
-	lb.Scores = append(lb.Scores, score)
-
-	// Keep only last 100 scores
-	if len(lb.Scores) > 100 {
-		lb.Scores = lb.Scores[len(lb.Scores)-100:]
-	}
+// Pages returns the number of pages of the given size in the history
+func (lb *Leaderboard) Pages(size int) int {
+	return max(1, (len(lb.Scores)+size-1)/size)
+}
 
-	lb.save()
+// GetRecent returns the n most recent scores, newest first
+func (lb *Leaderboard) GetRecent(n int) []Score {
+	return lb.Page(0, n)
 }
 
 // GetPB returns the personal best WPM for a mode (or overall if mode is empty)
 func (lb *Leaderboard) GetPB(mode string) *Score {
+	return lb.FindPB(ScoreFilter{Mode: mode})
+}
+
+// FindPB returns the best score among those matching the filter.
+// Lookups by mode alone are answered from the PB index.
+func (lb *Leaderboard) FindPB(filter ScoreFilter) *Score {
+	if filter == (ScoreFilter{Mode: filter.Mode}) {
+		if i, ok := lb.pbIndex()[filter.Mode]; ok {
+			return &lb.Scores[i]
+		}
+		return nil
+	}
+
 	var best *Score
 
 	for i := range lb.Scores {
 		score := &lb.Scores[i]
-
-		// Filter by mode if specified
-		if mode != "" && score.Mode != mode {
+		if !filter.Matches(*score) {
 			continue
 		}
 
@@ -108,20 +154,62 @@ func (lb *Leaderboard) GetPB(mode string) *Score {
 	return best
 }
 
+// pbIndex returns the index of the best score of each mode in Scores, with
+// the overall best under "", building it on first use
+func (lb *Leaderboard) pbIndex() map[string]int {
+	if lb.pbs == nil {
+		lb.pbs = make(map[string]int)
+		for i := range lb.Scores {
+			lb.indexScore(i)
+		}
+	}
+	return lb.pbs
+}
+
+// indexScore updates the PB index with the score at index i
+func (lb *Leaderboard) indexScore(i int) {
+	if lb.pbs == nil {
+		return // Built in full on first use
+	}
+	score := lb.Scores[i]
+	for _, mode := range []string{score.Mode, ""} {
+		if best, ok := lb.pbs[mode]; !ok || score.WPM > lb.Scores[best].WPM {
+			lb.pbs[mode] = i
+		}
+	}
+}
+
 // GetOverallPB returns the best score across all modes
 func (lb *Leaderboard) GetOverallPB() *Score {
 	return lb.GetPB("")
 }
 
-// GetTopScores returns top N scores for a mode
-func (lb *Leaderboard) GetTopScores(mode string, n int) []Score {
+// Filter returns the scores matching the filter, oldest first
+func (lb *Leaderboard) Filter(filter ScoreFilter) []Score {
 	var filtered []Score
-
 	for _, score := range lb.Scores {
-		if mode == "" || score.Mode == mode {
+		if filter.Matches(score) {
 			filtered = append(filtered, score)
 		}
 	}
+	return filtered
+}
+
+// Modes returns every mode in the history, sorted
+func (lb *Leaderboard) Modes() []string {
+	var modes []string
+	for mode := range lb.pbIndex() {
+		if mode != "" {
+			modes = append(modes, mode)
+		}
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// GetTopScores returns top N scores for a mode
+func (lb *Leaderboard) GetTopScores(mode string, n int) []Score {
+	filtered := lb.Filter(ScoreFilter{Mode: mode})
 
 	// Sort by WPM descending
 	sort.Slice(filtered, func(i, j int) bool {
@@ -137,6 +225,12 @@ func (lb *Leaderboard) GetTopScores(mode string, n int) []Score {
 
 // IsPB checks if a WPM would be a new personal best for a mode
 func (lb *Leaderboard) IsPB(wpm int, mode string) bool {
-	pb := lb.GetPB(mode)
+	return lb.BeatsPB(wpm, ScoreFilter{Mode: mode})
+}
+
+// BeatsPB checks if a WPM would be a new personal best among the scores
+// matching the filter
+func (lb *Leaderboard) BeatsPB(wpm int, filter ScoreFilter) bool {
+	pb := lb.FindPB(filter)
 	return pb == nil || wpm > pb.WPM
 }
@@ -2,14 +2,18 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestNewLeaderboard(t *testing.T) {
-	lb := NewLeaderboard()
+	lb := NewLeaderboard(NewRoot(t.TempDir()))
 
 	if lb == nil {
 		t.Fatal("NewLeaderboard returned nil")
@@ -33,7 +37,7 @@ func TestLeaderboardAddScore(t *testing.T) {
 		path:   filepath.Join(tempDir, "test_scores.json"),
 	}
 
-	lb.AddScore(65, 95, "time:30")
+	lb.AddScore(Score{WPM: 65, Accuracy: 95, Mode: "time:30"})
 
 	if len(lb.Scores) != 1 {
 		t.Errorf("Expected 1 score, got %d", len(lb.Scores))
@@ -116,6 +120,26 @@ func TestLeaderboardIsPB(t *testing.T) {
 	}
 }
 
+func TestLeaderboardBeatsPB(t *testing.T) {
+	lb := &Leaderboard{
+		Scores: []Score{
+			{WPM: 90, Mode: "time:30", Difficulty: "easy", Complexity: "normal", Date: time.Now()},
+			{WPM: 60, Mode: "time:30", Difficulty: "hard", Complexity: "full", Date: time.Now()},
+		},
+	}
+
+	hard := ScoreFilter{Mode: "time:30", Difficulty: "hard", Complexity: "full"}
+	if !lb.BeatsPB(70, hard) {
+		t.Error("70 WPM should beat the hard/full PB of 60, whatever the easy PB")
+	}
+	if lb.BeatsPB(70, ScoreFilter{Mode: "time:30"}) {
+		t.Error("70 WPM should not beat the overall time:30 PB of 90")
+	}
+	if !lb.BeatsPB(10, ScoreFilter{Mode: "time:30", Difficulty: "medium"}) {
+		t.Error("The first score with these settings should be a PB")
+	}
+}
+
 func TestLeaderboardGetTopScores(t *testing.T) {
 	lb := &Leaderboard{
 		Scores: []Score{
@@ -218,25 +242,27 @@ func TestLeaderboardLoadNonExistentFile(t *testing.T) {
 	}
 }
 
-func TestLeaderboardScoreLimit(t *testing.T) {
+func TestLeaderboardKeepsAllScores(t *testing.T) {
 	tempDir := t.TempDir()
 	lb := &Leaderboard{
 		Scores: []Score{},
-		path:   filepath.Join(tempDir, "scores.json"),
+		path:   filepath.Join(tempDir, "history.jsonl"),
 	}
 
 	// Add 105 scores
 	for i := 0; i < 105; i++ {
-		lb.AddScore(i, 90, "time:30")
+		lb.AddScore(Score{WPM: i, Accuracy: 90, Mode: "time:30"})
 	}
 
-	if len(lb.Scores) != 100 {
-		t.Errorf("Expected 100 scores (limit), got %d", len(lb.Scores))
+	// History is no longer capped at 100 entries
+	if len(lb.Scores) != 105 {
+		t.Errorf("Expected all 105 scores, got %d", len(lb.Scores))
 	}
 
-	// Verify it kept the most recent scores
-	if lb.Scores[0].WPM != 5 {
-		t.Error("Should have trimmed oldest scores, keeping most recent")
+	loaded := &Leaderboard{Scores: []Score{}, path: lb.path}
+	loaded.load()
+	if len(loaded.Scores) != 105 || loaded.Scores[0].WPM != 0 {
+		t.Errorf("Expected all scores on disk, oldest first, got %d", len(loaded.Scores))
 	}
 }
 
@@ -270,3 +296,317 @@ func TestScoreJSONSerialization(t *testing.T) {
 		t.Errorf("Mode mismatch: got %q, want %q", decoded.Mode, score.Mode)
 	}
 }
+
+func TestLeaderboardGetRecent(t *testing.T) {
+	lb := &Leaderboard{
+		Scores: []Score{
+			{WPM: 40, Mode: "time:30"},
+			{WPM: 50, Mode: "time:30"},
+			{WPM: 60, Mode: "words:25"},
+		},
+	}
+
+	recent := lb.GetRecent(2)
+	if len(recent) != 2 {
+		t.Fatalf("Expected 2 scores, got %d", len(recent))
+	}
+	if recent[0].WPM != 60 || recent[1].WPM != 50 {
+		t.Errorf("Expected newest first (60, 50), got (%d, %d)", recent[0].WPM, recent[1].WPM)
+	}
+
+	if len(lb.GetRecent(10)) != 3 {
+		t.Error("Expected all scores when asking for more than available")
+	}
+}
+
+func TestLeaderboardConcurrentInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+
+	// Two instances that loaded the same empty file
+	lb1 := &Leaderboard{Scores: []Score{}, path: path}
+	lb2 := &Leaderboard{Scores: []Score{}, path: path}
+
+	lb1.AddScore(Score{WPM: 60, Accuracy: 95, Mode: "time:30"})
+	lb2.AddScore(Score{WPM: 70, Accuracy: 97, Mode: "time:30"})
+
+	if len(lb2.Scores) != 2 {
+		t.Errorf("Expected the second instance to pick up the first's score, got %d scores", len(lb2.Scores))
+	}
+
+	// Many writers at once must not lose anything either
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lb := &Leaderboard{Scores: []Score{}, path: path}
+			lb.AddScore(Score{WPM: 50 + i, Accuracy: 90, Mode: "words:25"})
+		}()
+	}
+	wg.Wait()
+
+	loaded := &Leaderboard{Scores: []Score{}, path: path}
+	loaded.load()
+	if len(loaded.Scores) != 12 {
+		t.Errorf("Expected 12 scores on disk, got %d", len(loaded.Scores))
+	}
+}
+
+func TestLeaderboardFindPB(t *testing.T) {
+	lb := &Leaderboard{
+		Scores: []Score{
+			{WPM: 90, Mode: "time:30", Difficulty: "easy", Complexity: "normal"},
+			{WPM: 60, Mode: "time:30", Difficulty: "hard", Complexity: "full"},
+			{WPM: 70, Mode: "time:30", Difficulty: "hard", Complexity: "normal"},
+			{WPM: 80, Mode: "time:30", WordList: "go", Complexity: "normal"},
+		},
+	}
+
+	if pb := lb.GetPB("time:30"); pb == nil || pb.WPM != 90 {
+		t.Errorf("Expected overall PB of 90, got %+v", pb)
+	}
+
+	pb := lb.FindPB(ScoreFilter{Mode: "time:30", Difficulty: "hard", Complexity: "full"})
+	if pb == nil || pb.WPM != 60 {
+		t.Errorf("Expected hard/full PB of 60, got %+v", pb)
+	}
+
+	if n := len(lb.Filter(ScoreFilter{Difficulty: "hard"})); n != 2 {
+		t.Errorf("Expected 2 hard scores, got %d", n)
+	}
+	if n := len(lb.Filter(ScoreFilter{WordList: "go"})); n != 1 {
+		t.Errorf("Expected 1 score from the word list, got %d", n)
+	}
+	if pb := lb.FindPB(ScoreFilter{Difficulty: "medium"}); pb != nil {
+		t.Errorf("Expected no medium PB, got %+v", pb)
+	}
+}
+
+func TestLeaderboardAddScoreKeepsResult(t *testing.T) {
+	lb := &Leaderboard{Scores: []Score{}, path: filepath.Join(t.TempDir(), "scores.json")}
+	lb.AddScore(Score{WPM: 70, RawWPM: 75, Accuracy: 96, Mode: "time:30", Difficulty: "hard", Duration: 30 * time.Second, ExtraChars: 2})
+
+	loaded := &Leaderboard{Scores: []Score{}, path: lb.path}
+	loaded.load()
+	if len(loaded.Scores) != 1 {
+		t.Fatalf("Expected 1 score, got %d", len(loaded.Scores))
+	}
+	score := loaded.Scores[0]
+	if score.RawWPM != 75 || score.Difficulty != "hard" || score.Duration != 30*time.Second || score.ExtraChars != 2 {
+		t.Errorf("Expected the full result to round-trip, got %+v", score)
+	}
+	if score.Date.IsZero() {
+		t.Error("Expected the date to be set")
+	}
+}
+
+func TestLeaderboardPage(t *testing.T) {
+	lb := &Leaderboard{}
+	for i := 1; i <= 20; i++ {
+		lb.Scores = append(lb.Scores, Score{WPM: i})
+	}
+
+	if pages := lb.Pages(9); pages != 3 {
+		t.Errorf("Expected 3 pages, got %d", pages)
+	}
+
+	first := lb.Page(0, 9)
+	if len(first) != 9 || first[0].WPM != 20 || first[8].WPM != 12 {
+		t.Errorf("Unexpected first page %+v", first)
+	}
+
+	last := lb.Page(2, 9)
+	if len(last) != 2 || last[0].WPM != 2 || last[1].WPM != 1 {
+		t.Errorf("Unexpected last page %+v", last)
+	}
+
+	if len(lb.Page(3, 9)) != 0 {
+		t.Error("Expected an empty page past the end")
+	}
+}
+
+func TestLeaderboardPBIndex(t *testing.T) {
+	lb := &Leaderboard{Scores: []Score{}, path: filepath.Join(t.TempDir(), "history.jsonl")}
+	lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+	lb.AddScore(Score{WPM: 80, Mode: "words:25"})
+
+	if pb := lb.GetPB("time:30"); pb == nil || pb.WPM != 60 {
+		t.Errorf("Expected time:30 PB of 60, got %+v", pb)
+	}
+
+	// The index follows new scores
+	lb.AddScore(Score{WPM: 70, Mode: "time:30"})
+	if pb := lb.GetPB("time:30"); pb == nil || pb.WPM != 70 {
+		t.Errorf("Expected time:30 PB of 70, got %+v", pb)
+	}
+	if pb := lb.GetOverallPB(); pb == nil || pb.WPM != 80 {
+		t.Errorf("Expected overall PB of 80, got %+v", pb)
+	}
+	if pb := lb.GetPB("zen"); pb != nil {
+		t.Errorf("Expected no zen PB, got %+v", pb)
+	}
+}
+
+func TestLeaderboardAppendsOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lb := &Leaderboard{Scores: []Score{}, path: path}
+	lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+	before, _ := os.ReadFile(path)
+
+	lb.AddScore(Score{WPM: 70, Mode: "time:30"})
+	after, _ := os.ReadFile(path)
+
+	if !strings.HasPrefix(string(after), string(before)) {
+		t.Error("Expected adding a score to leave existing entries untouched")
+	}
+	if lines := strings.Count(string(after), "\n"); lines != 3 {
+		t.Errorf("Expected a header and 2 entries, got %d lines", lines)
+	}
+}
+
+func TestLeaderboardSkipsTruncatedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lb := &Leaderboard{Scores: []Score{}, path: path}
+	lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+
+	// A crash in the middle of appending leaves half a line behind
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"wpm":7`)
+	f.Close()
+	TakeNotices()
+
+	loaded := &Leaderboard{Scores: []Score{}, path: path}
+	loaded.load()
+	if len(loaded.Scores) != 1 {
+		t.Fatalf("Expected the intact score, got %+v", loaded.Scores)
+	}
+
+	// New scores start on a line of their own, which ends the damaged one
+	loaded.AddScore(Score{WPM: 80, Mode: "time:30"})
+	reloaded := &Leaderboard{Scores: []Score{}, path: path}
+	reloaded.load()
+	if len(reloaded.Scores) != 2 || reloaded.Scores[1].WPM != 80 {
+		t.Errorf("Expected the new score after the damaged line, got %+v", reloaded.Scores)
+	}
+	if len(TakeNotices()) != 1 {
+		t.Error("Expected a notice about the damaged entry")
+	}
+}
+
+func TestLeaderboardWaitsForUnfinishedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lb := &Leaderboard{Scores: []Score{}, path: path}
+	lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+
+	other := &Leaderboard{Scores: []Score{}, path: path}
+	other.load()
+
+	// Seen half written, an entry isn't consumed, so it is read once complete
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"wpm":70,"mode":"time:30",`)
+	withLock(path, other.readNew)
+	f.WriteString(`"date":"2026-01-02T15:04:05Z"}` + "\n")
+	f.Close()
+
+	other.AddScore(Score{WPM: 80, Mode: "time:30"})
+	if len(other.Scores) != 3 || other.Scores[1].WPM != 70 {
+		t.Errorf("Expected the completed entry to be read, got %+v", other.Scores)
+	}
+}
+
+func TestLeaderboardAppendConvertsOlderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lb := &Leaderboard{Scores: []Score{}, path: path}
+	lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+
+	// Pretend this build writes a newer format than the file on disk
+	saved := historySchema
+	defer func() { historySchema = saved }()
+	historySchema = schema{"history", append(append([]migration{}, saved.migrations...), addVersion)}
+
+	loaded := &Leaderboard{Scores: []Score{}, path: path}
+	loaded.load()
+	loaded.AddScore(Score{WPM: 70, Mode: "time:30"})
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), fmt.Sprintf(`{"format":"ktype-history","version":%d}`, historySchema.version())) {
+		t.Errorf("Expected the file to be rewritten at the current version, got %s", data)
+	}
+	reloaded := &Leaderboard{Scores: []Score{}, path: path}
+	reloaded.load()
+	if len(reloaded.Scores) != 2 {
+		t.Errorf("Expected both scores after the conversion, got %+v", reloaded.Scores)
+	}
+}
+
+func TestLeaderboardAddScoreReportsWriteErrors(t *testing.T) {
+	// A file where the data directory should be makes every write fail
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	os.WriteFile(blocked, nil, 0644)
+
+	lb := &Leaderboard{Scores: []Score{}, path: filepath.Join(blocked, "history.jsonl")}
+	score, err := lb.AddScore(Score{WPM: 60, Mode: "time:30"})
+	if err == nil {
+		t.Error("Expected an error when the history can't be written")
+	}
+	if score.Date.IsZero() || len(lb.Scores) != 1 {
+		t.Errorf("Expected the score to be kept for the session, got %+v", lb.Scores)
+	}
+}
+
+func TestLeaderboardLeavesNewerFileAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	newer := `{"format":"ktype-history","version":99}` + "\n" + `{"wpm":60,"mode":"time:30","date":"2026-01-02T15:04:05Z"}` + "\n"
+	os.WriteFile(path, []byte(newer), 0644)
+	TakeNotices()
+
+	lb := &Leaderboard{Scores: []Score{}, path: path}
+	lb.load()
+	if len(lb.Scores) != 1 {
+		t.Errorf("Expected the newer file to be read, got %+v", lb.Scores)
+	}
+	if notices := TakeNotices(); len(notices) != 1 || !strings.Contains(notices[0], "read-only") {
+		t.Errorf("Expected a read-only notice, got %v", notices)
+	}
+
+	if _, err := lb.AddScore(Score{WPM: 70, Mode: "time:30"}); !errors.Is(err, errNewerVersion) {
+		t.Errorf("Expected errNewerVersion adding to a newer file, got %v", err)
+	}
+	if _, err := lb.Merge([]Score{{WPM: 80, Mode: "time:30", Date: time.Now()}}); !errors.Is(err, errNewerVersion) {
+		t.Errorf("Expected errNewerVersion merging into a newer file, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("Expected the newer file to be left alone, got %s", data)
+	}
+}
+
+func TestLeaderboardRecoversFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	lb := &Leaderboard{Scores: []Score{{WPM: 60, Mode: "time:30"}}, path: path}
+	lb.save()
+	lb.Scores = append(lb.Scores, Score{WPM: 70, Mode: "time:30"})
+	lb.save()
+
+	os.WriteFile(path, []byte("garbage\n"), 0644)
+	TakeNotices()
+
+	loaded := &Leaderboard{Scores: []Score{}, path: path}
+	loaded.load()
+	if len(loaded.Scores) != 1 || loaded.Scores[0].WPM != 60 {
+		t.Errorf("Expected the backup's single score, got %+v", loaded.Scores)
+	}
+	if notices := TakeNotices(); len(notices) != 1 || !strings.Contains(notices[0], "restored") {
+		t.Errorf("Expected a recovery notice, got %v", notices)
+	}
+}
+
+func TestLeaderboardModes(t *testing.T) {
+	lb := NewLeaderboard(NewRoot(t.TempDir()))
+	for _, mode := range []string{"words:25", "time:30", "words:25", "time:15"} {
+		lb.AddScore(Score{WPM: 50, Accuracy: 90, Mode: mode})
+	}
+
+	if got := strings.Join(lb.Modes(), " "); got != "time:15 time:30 words:25" {
+		t.Errorf("Modes() = %q", got)
+	}
+}
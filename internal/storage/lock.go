@@ -0,0 +1,18 @@
+package storage
+
+// lockPath returns the path of the lock file guarding a data file
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// withLock runs fn while holding the advisory lock for the data file at path.
+// Every read-modify-write of a data file goes through here, so ktype instances
+// running side by side take turns instead of overwriting each other's changes.
+func withLock(path string, fn func() error) error {
+	unlock, err := lockFile(lockPath(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
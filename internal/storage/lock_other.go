@@ -0,0 +1,42 @@
+//go:build !unix
+
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const (
+	lockRetryDelay = 10 * time.Millisecond
+	lockTimeout    = 5 * time.Second
+	lockStaleAfter = 30 * time.Second // A lock this old was left by a crashed instance
+)
+
+// lockFile takes the lock by creating the file at path exclusively, retrying
+// while another instance holds it. Locks left behind by a crash are broken
+// once they are old enough.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("timed out waiting for " + path)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
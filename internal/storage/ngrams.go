@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NGramStats tracks how fast and how accurately a sequence of characters,
+// such as "th" or "ion", is typed
+type NGramStats struct {
+	NGram    string        `json:"ngram"`
+	Count    int           `json:"count"`
+	Errors   int           `json:"errors"`  // Occurrences with at least one mistyped key
+	Latency  time.Duration `json:"latency"` // Time from first to last key, summed over all occurrences
+	LastUsed time.Time     `json:"last_used"`
+}
+
+// AverageLatency returns the mean time from the n-gram's first key to its last
+func (n *NGramStats) AverageLatency() time.Duration {
+	if n.Count == 0 {
+		return 0
+	}
+	return n.Latency / time.Duration(n.Count)
+}
+
+// ErrorRate returns the error percentage for this n-gram
+func (n *NGramStats) ErrorRate() float64 {
+	if n.Count == 0 {
+		return 0.0
+	}
+	return float64(n.Errors) / float64(n.Count) * 100.0
+}
+
+// NGramSample is one typed occurrence of an n-gram
+type NGramSample struct {
+	NGram   string
+	Latency time.Duration
+	Error   bool
+}
+
+// MinNGramCount is how often an n-gram must have been typed before it is
+// ranked, so a single slow occurrence doesn't top the list
+const MinNGramCount = 5
+
+// NGrams stores latency and error statistics for bigrams and trigrams across
+// all tests. It is updated once per test with the samples taken from the
+// test's keystrokes.
+type NGrams struct {
+	Version int                    `json:"version"`
+	Stats   map[string]*NGramStats `json:"ngrams"`
+	path    string
+}
+
+// NewNGrams creates or loads the n-gram statistics
+func NewNGrams(root Root) *NGrams {
+	ng := &NGrams{
+		Stats: make(map[string]*NGramStats),
+		path:  root.dataPath("ngrams.json"),
+	}
+
+	ng.load()
+	return ng
+}
+
+// load reads n-gram statistics from file
+func (ng *NGrams) load() {
+	ng.Stats = nil
+	if err := loadJSON(ng.path, ng, ngramsSchema); err != nil || ng.Stats == nil {
+		// Missing, or corrupt without a backup - start fresh
+		ng.Stats = make(map[string]*NGramStats)
+	}
+}
+
+// save writes n-gram statistics to file
+func (ng *NGrams) save() error {
+	ng.Version = ngramsSchema.version()
+	return saveJSON(ng.path, ng)
+}
+
+// update applies a change to the statistics on disk under the file lock, so
+// tests finished in other running instances are kept
+func (ng *NGrams) update(apply func()) error {
+	return withLock(ng.path, func() error {
+		ng.load()
+		apply()
+		return ng.save()
+	})
+}
+
+// Record adds the n-grams typed in one test
+func (ng *NGrams) Record(samples []NGramSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return ng.update(func() {
+		for _, sample := range samples {
+			key := ngramKey(sample.NGram)
+			stat, ok := ng.Stats[key]
+			if !ok {
+				stat = &NGramStats{NGram: key}
+				ng.Stats[key] = stat
+			}
+			stat.Count++
+			stat.Latency += sample.Latency
+			if sample.Error {
+				stat.Errors++
+			}
+			stat.LastUsed = now
+		}
+	})
+}
+
+// Get returns the statistics of one n-gram, or nil if it was never typed
+func (ng *NGrams) Get(ngram string) *NGramStats {
+	return ng.Stats[ngramKey(ngram)]
+}
+
+// ngramKey returns the key an n-gram is stored under: n-grams are counted
+// regardless of case
+func ngramKey(ngram string) string {
+	return strings.ToLower(ngram)
+}
+
+// ranked returns the n-grams of length n typed at least MinNGramCount times,
+// ordered by less, at most limit of them
+func (ng *NGrams) ranked(n, limit int, less func(a, b *NGramStats) bool) []*NGramStats {
+	var stats []*NGramStats
+	for _, stat := range ng.Stats {
+		if utf8.RuneCountInString(stat.NGram) == n && stat.Count >= MinNGramCount {
+			stats = append(stats, stat)
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if less(stats[i], stats[j]) {
+			return true
+		}
+		if less(stats[j], stats[i]) {
+			return false
+		}
+		return stats[i].NGram < stats[j].NGram
+	})
+
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
+// GetSlowest returns the n-grams of length n with the highest average latency
+func (ng *NGrams) GetSlowest(n, limit int) []*NGramStats {
+	return ng.ranked(n, limit, func(a, b *NGramStats) bool {
+		return a.AverageLatency() > b.AverageLatency()
+	})
+}
+
+// GetTopErrors returns the n-grams of length n with the highest error rate
+func (ng *NGrams) GetTopErrors(n, limit int) []*NGramStats {
+	stats := ng.ranked(n, len(ng.Stats), func(a, b *NGramStats) bool {
+		return a.ErrorRate() > b.ErrorRate()
+	})
+
+	// Only n-grams that actually have errors
+	for i, stat := range stats {
+		if stat.Errors == 0 {
+			stats = stats[:i]
+			break
+		}
+	}
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
+// Total returns the number of n-gram occurrences recorded
+func (ng *NGrams) Total() int {
+	total := 0
+	for _, stat := range ng.Stats {
+		total += stat.Count
+	}
+	return total
+}
+
+// Clear resets all n-gram statistics
+func (ng *NGrams) Clear() error {
+	return withLock(ng.path, func() error {
+		ng.Stats = make(map[string]*NGramStats)
+		return ng.save()
+	})
+}
+
+// Merge folds n-gram statistics from another profile in, keeping for each
+// n-gram whichever record has seen more occurrences, and returns how many
+// changed
+func (ng *NGrams) Merge(stats []NGramStats) (int, error) {
+	changed := 0
+	err := ng.update(func() {
+		for _, s := range stats {
+			key := ngramKey(s.NGram)
+			if key == "" {
+				continue
+			}
+			if existing, ok := ng.Stats[key]; ok && existing.Count >= s.Count {
+				continue
+			}
+			stat := s
+			stat.NGram = key
+			ng.Stats[key] = &stat
+			changed++
+		}
+	})
+	return changed, err
+}
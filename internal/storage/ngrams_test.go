@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// samples returns count samples of an n-gram, the first errors of them mistyped
+func samples(ngram string, latency time.Duration, count, errors int) []NGramSample {
+	var s []NGramSample
+	for i := 0; i < count; i++ {
+		s = append(s, NGramSample{NGram: ngram, Latency: latency, Error: i < errors})
+	}
+	return s
+}
+
+func TestNGramsRecord(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	ng := NewNGrams(root)
+
+	if err := ng.Record(samples("TH", 100*time.Millisecond, 2, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ng.Record(samples("th", 200*time.Millisecond, 2, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	stat := NewNGrams(root).Get("th")
+	if stat == nil {
+		t.Fatal("th should be stored after reloading")
+	}
+	if stat.Count != 4 || stat.Errors != 1 {
+		t.Errorf("Expected 4 occurrences with 1 error, got %+v", stat)
+	}
+	if stat.AverageLatency() != 150*time.Millisecond {
+		t.Errorf("Expected 150ms average, got %v", stat.AverageLatency())
+	}
+	if stat.ErrorRate() != 25 {
+		t.Errorf("Expected 25%% error rate, got %.1f", stat.ErrorRate())
+	}
+}
+
+func TestNGramsRanking(t *testing.T) {
+	ng := NewNGrams(NewRoot(t.TempDir()))
+	var all []NGramSample
+	all = append(all, samples("th", 90*time.Millisecond, 10, 0)...)
+	all = append(all, samples("ck", 250*time.Millisecond, 10, 4)...)
+	all = append(all, samples("qz", 900*time.Millisecond, MinNGramCount-1, 3)...) // Too rare to rank
+	all = append(all, samples("ion", 300*time.Millisecond, 10, 1)...)
+	all = append(all, samples("ed", 120*time.Millisecond, 10, 1)...)
+	if err := ng.Record(all); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, stat := range ng.GetSlowest(2, 10) {
+		names = append(names, stat.NGram)
+	}
+	if got := strings.Join(names, " "); got != "ck ed th" {
+		t.Errorf("Slowest bigrams = %q, want \"ck ed th\"", got)
+	}
+
+	names = nil
+	for _, stat := range ng.GetTopErrors(2, 10) {
+		names = append(names, stat.NGram)
+	}
+	if got := strings.Join(names, " "); got != "ck ed" {
+		t.Errorf("Most error-prone bigrams = %q, want \"ck ed\"", got)
+	}
+
+	if slowest := ng.GetSlowest(3, 1); len(slowest) != 1 || slowest[0].NGram != "ion" {
+		t.Errorf("Expected ion as the slowest trigram, got %+v", slowest)
+	}
+}
+
+func TestNGramsMerge(t *testing.T) {
+	ng := NewNGrams(NewRoot(t.TempDir()))
+	ng.Record(samples("th", 100*time.Millisecond, 3, 0))
+
+	stats := []NGramStats{
+		{NGram: "th", Count: 10, Latency: time.Second},
+		{NGram: "he", Count: 2, Latency: 200 * time.Millisecond},
+	}
+	changed, err := ng.Merge(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 || ng.Get("th").Count != 10 {
+		t.Errorf("Expected both n-grams to be taken, got %d changed and %+v", changed, ng.Get("th"))
+	}
+	if changed, _ = ng.Merge(stats); changed != 0 {
+		t.Errorf("Second merge changed %d n-grams, want 0", changed)
+	}
+}
+
+func TestNGramsMergeIgnoresCase(t *testing.T) {
+	ng := NewNGrams(NewRoot(t.TempDir()))
+	ng.Record(samples("th", 100*time.Millisecond, 3, 0))
+
+	// Hand-edited or foreign exports may not be lowercased
+	if _, err := ng.Merge([]NGramStats{{NGram: "TH", Count: 10, Latency: time.Second}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ng.Stats) != 1 || ng.Get("th").Count != 10 || ng.Get("th").NGram != "th" {
+		t.Errorf("Expected TH to replace th rather than sit beside it, got %+v", ng.Stats)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HomeEnv names the environment variable that points ktype at a single
+// directory for all of its files, e.g. a portable profile on a USB stick
+const HomeEnv = "KTYPE_HOME"
+
+// Root locates the directories ktype keeps its files in. Settings (config and
+// word lists) live in ConfigDir, history (scores, heatmap, challenges and
+// keystroke logs) in DataDir. Both may be the same directory.
+type Root struct {
+	ConfigDir string
+	DataDir   string
+}
+
+// NewRoot returns a root that keeps everything in dir
+func NewRoot(dir string) Root {
+	return Root{ConfigDir: dir, DataDir: dir}
+}
+
+// ResolveRoot works out where ktype's files live and creates the directories.
+// An explicit dir (the --data-dir flag) wins, then $KTYPE_HOME; both keep
+// everything in one place. Otherwise settings go to $XDG_CONFIG_HOME/ktype (or
+// the OS config directory) and history to $XDG_DATA_HOME/ktype. Without
+// XDG_DATA_HOME, or when history from before it was set is still in the config
+// directory, history stays next to the settings.
+func ResolveRoot(dir string) (Root, error) {
+	if dir == "" {
+		dir = os.Getenv(HomeEnv)
+	}
+	if dir != "" {
+		root := NewRoot(dir)
+		return root, root.ensure()
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(configHome) {
+		var err error
+		if configHome, err = os.UserConfigDir(); err != nil {
+			return Root{}, fmt.Errorf("can't find a config directory (set %s or use --data-dir): %w", HomeEnv, err)
+		}
+	}
+	root := NewRoot(filepath.Join(configHome, "ktype"))
+
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" && filepath.IsAbs(dataHome) {
+		dataDir := filepath.Join(dataHome, "ktype")
+		if !exists(dataDir) && (exists(filepath.Join(root.ConfigDir, "history.jsonl")) || exists(filepath.Join(root.ConfigDir, "scores.json"))) {
+			dataDir = root.ConfigDir
+		}
+		root.DataDir = dataDir
+	}
+
+	return root, root.ensure()
+}
+
+// ensure creates the root's directories
+func (r Root) ensure() error {
+	for _, dir := range []string{r.ConfigDir, r.DataDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("can't create data directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// configPath returns the path of a settings file
+func (r Root) configPath(name string) string {
+	return filepath.Join(r.ConfigDir, name)
+}
+
+// dataPath returns the path of a history file
+func (r Root) dataPath(name string) string {
+	return filepath.Join(r.DataDir, name)
+}
+
+// exists reports whether something is at path
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
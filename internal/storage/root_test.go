@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveRoot(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	t.Setenv(HomeEnv, "")
+
+	root, err := ResolveRoot("")
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root.ConfigDir != filepath.Join(base, "config", "ktype") {
+		t.Errorf("Expected config under XDG_CONFIG_HOME, got %s", root.ConfigDir)
+	}
+	if root.DataDir != filepath.Join(base, "data", "ktype") {
+		t.Errorf("Expected history under XDG_DATA_HOME, got %s", root.DataDir)
+	}
+	for _, dir := range []string{root.ConfigDir, root.DataDir} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("Expected %s to be created", dir)
+		}
+	}
+
+	// KTYPE_HOME keeps everything in one place
+	home := filepath.Join(base, "portable")
+	t.Setenv(HomeEnv, home)
+	root, err = ResolveRoot("")
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root != NewRoot(home) {
+		t.Errorf("Expected everything in KTYPE_HOME, got %+v", root)
+	}
+
+	// --data-dir takes precedence over KTYPE_HOME
+	flag := filepath.Join(base, "flag")
+	root, err = ResolveRoot(flag)
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root != NewRoot(flag) {
+		t.Errorf("Expected everything in the --data-dir directory, got %+v", root)
+	}
+}
+
+func TestResolveRootKeepsExistingHistory(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	t.Setenv(HomeEnv, "")
+
+	// History written before XDG_DATA_HOME was set
+	configDir := filepath.Join(base, "ktype")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "scores.json"), []byte(`{"scores":[]}`), 0644)
+
+	root, err := ResolveRoot("")
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root.DataDir != configDir {
+		t.Errorf("Expected history to stay in %s, got %s", configDir, root.DataDir)
+	}
+}
+
+func TestRootSeparatesSettingsAndHistory(t *testing.T) {
+	base := t.TempDir()
+	root := Root{ConfigDir: filepath.Join(base, "config"), DataDir: filepath.Join(base, "data")}
+	if err := root.ensure(); err != nil {
+		t.Fatalf("ensure failed: %v", err)
+	}
+
+	lb := NewLeaderboard(root)
+	lb.AddScore(Score{WPM: 60, Accuracy: 95, Mode: "time:30"})
+	wm := NewWordListManager(root)
+	wm.AddList("test", "", []string{"word"})
+
+	if _, err := os.Stat(filepath.Join(root.DataDir, "history.jsonl")); err != nil {
+		t.Error("Expected scores in the data directory")
+	}
+	if _, err := os.Stat(filepath.Join(root.ConfigDir, "wordlists.json")); err != nil {
+		t.Error("Expected word lists in the config directory")
+	}
+}
+
+func TestKeyLogDirCreatedOnWrite(t *testing.T) {
+	root := NewRoot(t.TempDir())
+	score := Score{Date: time.Now()}
+
+	// Looking a log up doesn't create anything
+	if root.HasKeyLog(score) {
+		t.Error("Expected no key log")
+	}
+	if _, err := os.Stat(root.KeyLogDir()); !os.IsNotExist(err) {
+		t.Errorf("Expected no keylogs directory before a log is written, got %v", err)
+	}
+
+	if err := WriteKeyLog(root.KeyLogPath(KeyLogID(score.Date)), []byte("{}")); err != nil {
+		t.Fatalf("WriteKeyLog failed: %v", err)
+	}
+	if !root.HasKeyLog(score) {
+		t.Error("Expected the key log to be found once written")
+	}
+}
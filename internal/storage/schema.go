@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// migration upgrades a decoded data file by one version, in place
+type migration func(doc map[string]any) error
+
+// schema describes the layout history of one kind of data file. migrations[i]
+// upgrades a file from version i to version i+1, so the current version is the
+// number of migrations. Version 0 is the original layout, which had no version
+// field. To change a file's layout, append the step that converts the previous
+// version and add a fixture of that version under testdata.
+type schema struct {
+	name       string
+	migrations []migration
+}
+
+// Schemas of every file ktype writes
+var (
+	scoresSchema     = schema{"scores", []migration{addVersion, addScoresSettings}}
+	historySchema    = schema{"history", []migration{addVersion, addEntrySettings}}
+	heatmapSchema    = schema{"heatmap", []migration{dropTruncatedKeys}}
+	challengesSchema = schema{"challenges", []migration{addVersion}}
+	configSchema     = schema{"config", []migration{addVersion}}
+	wordListsSchema  = schema{"word lists", []migration{addVersion}}
+	keyLogSchema     = schema{"keystroke log", []migration{addVersion}}
+	ngramsSchema     = schema{"n-grams", []migration{addVersion}}
+)
+
+// version returns the version files of this kind are written with
+func (s schema) version() int {
+	return len(s.migrations)
+}
+
+// errNewerVersion is returned instead of saving over a file written by a newer
+// ktype, which would silently drop whatever that version added
+var errNewerVersion = errors.New("file is from a newer version of ktype")
+
+// fileVersion returns the version an encoded data file records, 0 if none
+func fileVersion(data []byte) int {
+	var file struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &file)
+	return file.Version
+}
+
+// upgrade runs the migrations data needs to reach the current version and
+// returns it re-encoded. Files from a newer ktype are passed through as they
+// are, with a notice that they are read-only: writeJSONFile won't save over
+// them.
+func (s schema) upgrade(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+
+	switch {
+	case version == s.version():
+		return data, nil
+	case version > s.version():
+		addNotice("%s data is from a newer version of ktype (v%d, this one knows v%d); it is read-only, so changes won't be saved", s.name, version, s.version())
+		return data, nil
+	case version < 0:
+		return nil, fmt.Errorf("invalid %s version %d", s.name, version)
+	}
+
+	if err := s.migrate(doc, version); err != nil {
+		return nil, err
+	}
+	doc["version"] = s.version()
+	return json.Marshal(doc)
+}
+
+// migrate runs the migrations from version onwards on doc
+func (s schema) migrate(doc map[string]any, version int) error {
+	for ; version < s.version(); version++ {
+		if err := s.migrations[version](doc); err != nil {
+			return fmt.Errorf("upgrading %s data from v%d: %w", s.name, version, err)
+		}
+	}
+	return nil
+}
+
+// KeyLogVersion returns the version keystroke logs are written with
+func KeyLogVersion() int {
+	return keyLogSchema.version()
+}
+
+// UpgradeKeyLog converts a keystroke log written by an earlier version of
+// ktype to the current layout
+func UpgradeKeyLog(data []byte) ([]byte, error) {
+	return keyLogSchema.upgrade(data)
+}
+
+// addVersion is the first step of every file: unversioned files only gain
+// the version field, which upgrade sets once all steps have run. For history
+// entries, version 0 is a score as it was stored in scores.json.
+func addVersion(doc map[string]any) error {
+	return nil
+}
+
+// addScoresSettings fills in the settings of the scores in a scores.json
+// written before scores recorded them. See addSettings.
+func addScoresSettings(doc map[string]any) error {
+	scores, _ := doc["scores"].([]any)
+	for _, s := range scores {
+		if score, ok := s.(map[string]any); ok {
+			addSettings(score)
+		}
+	}
+	return nil
+}
+
+// addEntrySettings fills in the settings of a history entry written before
+// scores recorded them. See addSettings.
+func addEntrySettings(doc map[string]any) error {
+	addSettings(doc)
+	return nil
+}
+
+// addSettings gives a score without recorded settings the defaults, medium
+// difficulty and normal complexity, so personal bests set before the
+// settings were recorded are still found for tests played with them. Scores
+// from a word list take its name from the mode instead of a difficulty, and
+// quotes have no settings.
+func addSettings(score map[string]any) {
+	mode, _ := score["mode"].(string)
+	if strings.HasPrefix(mode, "quote") {
+		return
+	}
+	if _, ok := score["complexity"]; !ok {
+		score["complexity"] = "normal"
+	}
+	if _, ok := score["word_list"]; ok {
+		return
+	}
+	if _, list, ok := strings.Cut(mode, "/list:"); ok {
+		list, _, _ = strings.Cut(list, "/")
+		score["word_list"] = list
+		return
+	}
+	if _, ok := score["difficulty"]; !ok {
+		score["difficulty"] = "medium"
+	}
+}
+
+// dropTruncatedKeys removes what unversioned heatmaps recorded for non-ASCII
+// keys. They were cut to their first byte, which is not valid UTF-8 on its own
+// and was written out as U+FFFD, so every such key shares one meaningless entry.
+func dropTruncatedKeys(doc map[string]any) error {
+	keys, ok := doc["keys"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	delete(keys, "\ufffd")
+	return nil
+}
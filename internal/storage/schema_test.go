@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureVersions returns the versions with fixtures under testdata
+func fixtureVersions(t *testing.T) []string {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "v*"))
+	if err != nil || len(dirs) == 0 {
+		t.Fatal("Expected fixture directories under testdata")
+	}
+	return dirs
+}
+
+// copyFixture copies a fixture into dir and returns its new path
+func copyFixture(t *testing.T, fixtureDir, name, dir string) string {
+	data, err := os.ReadFile(filepath.Join(fixtureDir, name))
+	if err != nil {
+		t.Fatalf("Missing fixture %s: %v", filepath.Join(fixtureDir, name), err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// savedVersion reads the version field of a saved file
+func savedVersion(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &doc)
+	return doc.Version
+}
+
+func TestFixturesCoverEverySchemaVersion(t *testing.T) {
+	for _, s := range []schema{scoresSchema, historySchema, heatmapSchema, challengesSchema, configSchema, wordListsSchema, ngramsSchema} {
+		for v := 0; v <= s.version(); v++ {
+			if _, err := os.Stat(filepath.Join("testdata", fmt.Sprintf("v%d", v))); err != nil {
+				t.Errorf("Expected fixtures for %s v%d", s.name, v)
+			}
+		}
+	}
+}
+
+func TestMigrateScores(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		dir := t.TempDir()
+		legacy := copyFixture(t, fixtures, "scores.json", dir)
+
+		// scores.json from before the history file is converted on first load
+		lb := NewLeaderboard(NewRoot(dir))
+		if len(lb.Scores) != 2 || lb.Scores[0].WPM != 72 || lb.Scores[1].Mode != "words:25" {
+			t.Errorf("%s: unexpected scores %+v", fixtures, lb.Scores)
+		}
+		if _, err := os.Stat(legacy + ".migrated"); err != nil {
+			t.Errorf("%s: expected the old file to be kept as scores.json.migrated", fixtures)
+		}
+
+		reloaded := NewLeaderboard(NewRoot(dir))
+		if len(reloaded.Scores) != 2 {
+			t.Errorf("%s: expected the converted history to load, got %d scores", fixtures, len(reloaded.Scores))
+		}
+	}
+}
+
+func TestLoadHistoryFixture(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		if _, err := os.Stat(filepath.Join(fixtures, "history.jsonl")); err != nil {
+			continue // History files were introduced in v1
+		}
+		path := copyFixture(t, fixtures, "history.jsonl", t.TempDir())
+		lb := &Leaderboard{Scores: []Score{}, path: path}
+		lb.load()
+
+		if len(lb.Scores) != 2 || lb.Scores[0].Difficulty != "hard" || lb.Scores[1].RawWPM != 70 {
+			t.Errorf("%s: unexpected scores %+v", fixtures, lb.Scores)
+		}
+
+		// Adding a score brings the whole file up to the current version
+		lb.AddScore(Score{WPM: 80, Mode: "time:30"})
+		data, _ := os.ReadFile(path)
+		if !strings.HasPrefix(string(data), fmt.Sprintf(`{"format":"ktype-history","version":%d}`, historySchema.version())) {
+			t.Errorf("%s: expected the file to be saved at version %d, got %s", fixtures, historySchema.version(), data)
+		}
+	}
+}
+
+func TestLegacyPBsFoundBySettings(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		dir := t.TempDir()
+		copyFixture(t, fixtures, "scores.json", dir)
+
+		// The filter a medium, normal test of the mode looks its PB up with
+		lb := NewLeaderboard(NewRoot(dir))
+		pb := lb.FindPB(ScoreFilter{Mode: "time:30", Difficulty: "medium", Complexity: "normal"})
+		if pb == nil || pb.WPM != 72 {
+			t.Errorf("%s: expected the 72 WPM PB, got %+v", fixtures, pb)
+		}
+		if lb.BeatsPB(60, ScoreFilter{Mode: "words:25", Difficulty: "medium", Complexity: "normal"}) {
+			t.Errorf("%s: expected 60 WPM not to beat the words:25 PB of 65", fixtures)
+		}
+	}
+}
+
+func TestAddSettings(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Score
+	}{
+		{`{"wpm":60,"mode":"time:30"}`, Score{WPM: 60, Mode: "time:30", Difficulty: "medium", Complexity: "normal"}},
+		{`{"wpm":60,"mode":"time:30/list:german/stop:word"}`, Score{WPM: 60, Mode: "time:30/list:german/stop:word", Complexity: "normal", WordList: "german"}},
+		{`{"wpm":60,"mode":"quote:short"}`, Score{WPM: 60, Mode: "quote:short"}},
+		{`{"wpm":60,"mode":"words:25","difficulty":"hard","complexity":"full"}`, Score{WPM: 60, Mode: "words:25", Difficulty: "hard", Complexity: "full"}},
+		{`{"wpm":60,"mode":"zen/list:go","complexity":"normal","word_list":"go"}`, Score{WPM: 60, Mode: "zen/list:go", Complexity: "normal", WordList: "go"}},
+	}
+
+	for _, tt := range tests {
+		score, err := decodeHistoryEntry([]byte(tt.line), 1)
+		if err != nil {
+			t.Fatalf("decodeHistoryEntry(%s) failed: %v", tt.line, err)
+		}
+		if score != tt.expected {
+			t.Errorf("decodeHistoryEntry(%s) = %+v, expected %+v", tt.line, score, tt.expected)
+		}
+	}
+}
+
+func TestMigrateHeatmap(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		path := copyFixture(t, fixtures, "heatmap.json", t.TempDir())
+		hm := &Heatmap{Keys: make(map[string]*KeyStats), path: path}
+		hm.load()
+
+		if hm.Keys["a"] == nil || hm.Keys["a"].TotalHits != 120 || hm.Keys[" "] == nil {
+			t.Errorf("%s: unexpected keys %+v", fixtures, hm.Keys)
+		}
+		// Truncated multibyte keys from unversioned files are dropped
+		if _, ok := hm.Keys["�"]; ok {
+			t.Errorf("%s: expected the truncated key entry to be removed", fixtures)
+		}
+
+		hm.save()
+		if v := savedVersion(t, path); v != heatmapSchema.version() {
+			t.Errorf("%s: expected saved version %d, got %d", fixtures, heatmapSchema.version(), v)
+		}
+	}
+}
+
+func TestMigrateChallenges(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		path := copyFixture(t, fixtures, "challenges.json", t.TempDir())
+		dc := &DailyChallenges{Challenges: []Challenge{}, path: path}
+		dc.load()
+
+		if len(dc.Challenges) != 1 || !dc.Challenges[0].Completed || dc.Challenges[0].Progress != 72 {
+			t.Errorf("%s: unexpected challenges %+v", fixtures, dc.Challenges)
+		}
+		if dc.Version != challengesSchema.version() {
+			t.Errorf("%s: expected version %d after load, got %d", fixtures, challengesSchema.version(), dc.Version)
+		}
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		path := copyFixture(t, fixtures, "config.json", t.TempDir())
+		cm := &ConfigManager{config: DefaultConfig(), path: path}
+		cm.load()
+
+		cfg := cm.GetConfig()
+		if cfg.CursorType != 1 || cfg.AccentColor != "#98c379" || cfg.ShowHeatmap {
+			t.Errorf("%s: unexpected config %+v", fixtures, cfg)
+		}
+		// Settings added later keep their defaults
+		if cfg.GhostWPM != 60 {
+			t.Errorf("%s: expected defaults for newer settings, got %+v", fixtures, cfg)
+		}
+		if cfg.Version != configSchema.version() {
+			t.Errorf("%s: expected version %d after load, got %d", fixtures, configSchema.version(), cfg.Version)
+		}
+	}
+}
+
+func TestMigrateWordLists(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		path := copyFixture(t, fixtures, "wordlists.json", t.TempDir())
+		wm := &WordListManager{Lists: []WordList{}, path: path}
+		wm.load()
+
+		list := wm.GetList("go")
+		if list == nil || len(list.Words) != 3 || list.Description != "Go keywords" {
+			t.Errorf("%s: unexpected word lists %+v", fixtures, wm.Lists)
+		}
+		if wm.Version != wordListsSchema.version() {
+			t.Errorf("%s: expected version %d after load, got %d", fixtures, wordListsSchema.version(), wm.Version)
+		}
+	}
+}
+
+func TestMigrateNGrams(t *testing.T) {
+	for _, fixtures := range fixtureVersions(t) {
+		path := copyFixture(t, fixtures, "ngrams.json", t.TempDir())
+		ng := &NGrams{path: path}
+		ng.load()
+
+		stat := ng.Get("th")
+		if stat == nil || stat.Count != 12 || stat.AverageLatency() != 120*time.Millisecond {
+			t.Errorf("%s: unexpected n-grams %+v", fixtures, ng.Stats)
+		}
+
+		ng.Record([]NGramSample{{NGram: "he", Latency: time.Second}})
+		if v := savedVersion(t, path); v != ngramsSchema.version() {
+			t.Errorf("%s: expected saved version %d, got %d", fixtures, ngramsSchema.version(), v)
+		}
+	}
+}
+
+func TestUpgradeRunsEveryStep(t *testing.T) {
+	var steps []int
+	s := schema{"test", []migration{
+		func(doc map[string]any) error { steps = append(steps, 0); return nil },
+		func(doc map[string]any) error {
+			steps = append(steps, 1)
+			doc["renamed"] = doc["old"]
+			delete(doc, "old")
+			return nil
+		},
+	}}
+
+	data, err := s.upgrade([]byte(`{"old": "value"}`))
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Errorf("Expected both steps to run, got %v", steps)
+	}
+
+	var doc map[string]any
+	json.Unmarshal(data, &doc)
+	if doc["renamed"] != "value" || doc["version"] != float64(2) {
+		t.Errorf("Unexpected upgraded document %v", doc)
+	}
+
+	// A file at version 1 only needs the second step
+	steps = nil
+	s.upgrade([]byte(`{"version": 1, "old": "value"}`))
+	if len(steps) != 1 || steps[0] != 1 {
+		t.Errorf("Expected only the second step to run, got %v", steps)
+	}
+}
+
+func TestUpgradeNewerVersion(t *testing.T) {
+	TakeNotices()
+	data := []byte(`{"version": 99, "scores": []}`)
+
+	upgraded, err := scoresSchema.upgrade(data)
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if string(upgraded) != string(data) {
+		t.Error("Expected a newer file to be passed through unchanged")
+	}
+
+	notices := TakeNotices()
+	if len(notices) != 1 || !strings.Contains(notices[0], "newer version") {
+		t.Errorf("Expected a notice about the newer file, got %v", notices)
+	}
+}
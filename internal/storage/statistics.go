@@ -4,11 +4,18 @@ import (
 	"fmt"
 	"sort"
 	"time"
+
+	"ktype/internal/keyboard"
 )
 
 // Statistics provides comprehensive typing analytics
 type Statistics struct {
-	lb *Leaderboard
+	lb     *Leaderboard
+	filter ScoreFilter
+
+	// Keystroke statistics for the per-finger breakdown, see WithHeatmap
+	heatmap *Heatmap
+	layout  keyboard.Layout
 }
 
 // NewStatistics creates a new statistics analyzer
@@ -16,6 +23,67 @@ func NewStatistics(lb *Leaderboard) *Statistics {
 	return &Statistics{lb: lb}
 }
 
+// Where returns an analyzer that only looks at scores matching the filter.
+// It leaves out the heatmap, whose keystrokes aren't kept per test.
+func (s *Statistics) Where(filter ScoreFilter) *Statistics {
+	return &Statistics{lb: s.lb, filter: filter}
+}
+
+// WithHeatmap returns an analyzer that also breaks the heatmap's keystrokes
+// down by finger and hand on layout
+func (s *Statistics) WithHeatmap(hm *Heatmap, layout keyboard.Layout) *Statistics {
+	return &Statistics{lb: s.lb, filter: s.filter, heatmap: hm, layout: layout}
+}
+
+// GetFingerBreakdown returns error rate, share of keystrokes and speed per
+// finger and hand over every test ever typed. It is empty without a heatmap,
+// so also on filtered analyzers.
+func (s *Statistics) GetFingerBreakdown() FingerBreakdown {
+	if s.heatmap == nil {
+		return NewFingerBreakdown(nil, s.layout)
+	}
+	return s.heatmap.GetFingerBreakdown(s.layout)
+}
+
+// TimeWindow limits statistics to recent tests
+type TimeWindow int
+
+const (
+	WindowAll   TimeWindow = iota // Every test
+	WindowWeek                    // The last 7 days
+	WindowMonth                   // The last 30 days
+)
+
+// String returns the window's short label
+func (w TimeWindow) String() string {
+	switch w {
+	case WindowWeek:
+		return "7d"
+	case WindowMonth:
+		return "30d"
+	default:
+		return "all"
+	}
+}
+
+// Since returns the start of the window ending at now, or the zero time for
+// WindowAll
+func (w TimeWindow) Since(now time.Time) time.Time {
+	switch w {
+	case WindowWeek:
+		return now.AddDate(0, 0, -7)
+	case WindowMonth:
+		return now.AddDate(0, 0, -30)
+	default:
+		return time.Time{}
+	}
+}
+
+// scores returns the scores the analyzer covers
+func (s *Statistics) scores() []Score {
+	return s.lb.Filter(s.filter)
+}
+
 // StatsSummary provides a summary of typing statistics
 type StatsSummary struct {
 	TotalTests      int
@@ -28,7 +96,8 @@ type StatsSummary struct {
 
 // GetSummary returns overall statistics summary
 func (s *Statistics) GetSummary() StatsSummary {
-	if len(s.lb.Scores) == 0 {
+	scores := s.scores()
+	if len(scores) == 0 {
 		return StatsSummary{}
 	}
 
@@ -39,7 +108,7 @@ func (s *Statistics) GetSummary() StatsSummary {
 	recentTests := 0
 	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
 
-	for _, score := range s.lb.Scores {
+	for _, score := range scores {
 		totalWPM += score.WPM
 		totalAccuracy += score.Accuracy
 
@@ -55,7 +124,7 @@ func (s *Statistics) GetSummary() StatsSummary {
 		}
 	}
 
-	count := len(s.lb.Scores)
+	count := len(scores)
 	return StatsSummary{
 		TotalTests:      count,
 		AverageWPM:      float64(totalWPM) / float64(count),
@@ -80,7 +149,7 @@ type ModeStats struct {
 func (s *Statistics) GetModeStats() []ModeStats {
 	// Group scores by mode
 	modeScores := make(map[string][]Score)
-	for _, score := range s.lb.Scores {
+	for _, score := range s.scores() {
 		modeScores[score.Mode] = append(modeScores[score.Mode], score)
 	}
 
@@ -134,14 +203,12 @@ type TrendPoint struct {
 
 // GetWPMTrend returns WPM trend over time (last 30 tests)
 func (s *Statistics) GetWPMTrend(limit int) []TrendPoint {
-	if len(s.lb.Scores) == 0 {
+	// Get last N scores, sorted by date
+	scores := s.scores()
+	if len(scores) == 0 {
 		return []TrendPoint{}
 	}
 
-	// Get last N scores, sorted by date
-	scores := make([]Score, len(s.lb.Scores))
-	copy(scores, s.lb.Scores)
-
 	// Sort by date ascending
 	sort.Slice(scores, func(i, j int) bool {
 		return scores[i].Date.Before(scores[j].Date)
@@ -183,9 +250,10 @@ func (s *Statistics) GetWPMDistribution() []PerformanceRange {
 		{Label: "Master", Min: 120, Max: 999, Color: "#c678dd"},     // Purple
 	}
 
+	scores := s.scores()
 	for i := range ranges {
 		count := 0
-		for _, score := range s.lb.Scores {
+		for _, score := range scores {
 			if score.WPM >= ranges[i].Min && score.WPM < ranges[i].Max {
 				count++
 			}
@@ -205,24 +273,25 @@ type ConsistencyMetrics struct {
 
 // GetConsistencyMetrics calculates consistency metrics
 func (s *Statistics) GetConsistencyMetrics() ConsistencyMetrics {
-	if len(s.lb.Scores) < 3 {
+	scores := s.scores()
+	if len(scores) < 3 {
 		return ConsistencyMetrics{ConsistencyRating: "N/A"}
 	}
 
 	// Calculate mean
 	sumWPM := 0
 	sumAccuracy := 0
-	for _, score := range s.lb.Scores {
+	for _, score := range scores {
 		sumWPM += score.WPM
 		sumAccuracy += score.Accuracy
 	}
-	meanWPM := float64(sumWPM) / float64(len(s.lb.Scores))
-	meanAccuracy := float64(sumAccuracy) / float64(len(s.lb.Scores))
+	meanWPM := float64(sumWPM) / float64(len(scores))
+	meanAccuracy := float64(sumAccuracy) / float64(len(scores))
 
 	// Calculate variance
 	varianceWPM := 0.0
 	varianceAccuracy := 0.0
-	for _, score := range s.lb.Scores {
+	for _, score := range scores {
 		diffWPM := float64(score.WPM) - meanWPM
 		varianceWPM += diffWPM * diffWPM
 		diffAccuracy := float64(score.Accuracy) - meanAccuracy
@@ -230,8 +299,8 @@ func (s *Statistics) GetConsistencyMetrics() ConsistencyMetrics {
 	}
 
 	// Standard deviation
-	stdDevWPM := varianceWPM / float64(len(s.lb.Scores))
-	stdDevAccuracy := varianceAccuracy / float64(len(s.lb.Scores))
+	stdDevWPM := varianceWPM / float64(len(scores))
+	stdDevAccuracy := varianceAccuracy / float64(len(scores))
 
 	// Determine rating based on WPM std dev
 	rating := "Unpredictable"
@@ -270,7 +339,7 @@ func (s *Statistics) GetRecentPerformance() RecentPerformance {
 	monthCount := 0
 	todayWPM := 0
 
-	for _, score := range s.lb.Scores {
+	for _, score := range s.scores() {
 		if score.Date.After(today) {
 			todayCount++
 			todayWPM += score.WPM
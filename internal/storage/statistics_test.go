@@ -273,3 +273,67 @@ func TestGetRecentPerformance(t *testing.T) {
 	}
 }
 
+func TestStatisticsWhere(t *testing.T) {
+	lb := &Leaderboard{
+		Scores: []Score{
+			{WPM: 100, Accuracy: 98, Mode: "time:15", Difficulty: "easy", Date: time.Now()},
+			{WPM: 60, Accuracy: 94, Mode: "words:100", Difficulty: "hard", Date: time.Now()},
+			{WPM: 70, Accuracy: 96, Mode: "words:100", Difficulty: "hard", Date: time.Now()},
+		},
+	}
+	stats := NewStatistics(lb)
+
+	hard := stats.Where(ScoreFilter{Difficulty: "hard"}).GetSummary()
+	if hard.TotalTests != 2 || hard.AverageWPM != 65 || hard.BestWPM != 70 {
+		t.Errorf("Unexpected summary for hard scores: %+v", hard)
+	}
+
+	if all := stats.GetSummary(); all.TotalTests != 3 {
+		t.Errorf("Expected the unfiltered analyzer to keep all scores, got %d", all.TotalTests)
+	}
+
+	if trend := stats.Where(ScoreFilter{Mode: "time:15"}).GetWPMTrend(10); len(trend) != 1 || trend[0].WPM != 100 {
+		t.Errorf("Unexpected trend for time:15: %+v", trend)
+	}
+}
+
+func TestStatisticsWhereDateRange(t *testing.T) {
+	now := time.Now()
+	lb := &Leaderboard{
+		Scores: []Score{
+			{WPM: 40, Accuracy: 90, Mode: "time:30", Date: now.AddDate(0, 0, -40)},
+			{WPM: 50, Accuracy: 92, Mode: "time:30", Date: now.AddDate(0, 0, -20)},
+			{WPM: 60, Accuracy: 94, Mode: "time:30", Date: now.AddDate(0, 0, -3)},
+			{WPM: 90, Accuracy: 97, Mode: "time:15", Date: now.AddDate(0, 0, -1)},
+		},
+	}
+	stats := NewStatistics(lb)
+
+	tests := []struct {
+		window TimeWindow
+		tests  int
+	}{
+		{WindowAll, 4},
+		{WindowMonth, 3},
+		{WindowWeek, 2},
+	}
+	for _, tt := range tests {
+		summary := stats.Where(ScoreFilter{Since: tt.window.Since(now)}).GetSummary()
+		if summary.TotalTests != tt.tests {
+			t.Errorf("%v: got %d tests, want %d", tt.window, summary.TotalTests, tt.tests)
+		}
+	}
+
+	week := stats.Where(ScoreFilter{Mode: "time:30", Since: WindowWeek.Since(now)})
+	if dist := week.GetWPMDistribution(); dist[2].Count != 1 {
+		t.Errorf("Expected one advanced score this week in time:30, got %+v", dist)
+	}
+
+	older := stats.Where(ScoreFilter{Until: now.AddDate(0, 0, -7)})
+	if summary := older.GetSummary(); summary.TotalTests != 2 || summary.BestWPM != 50 {
+		t.Errorf("Unexpected summary before last week: %+v", summary)
+	}
+	if metrics := older.GetConsistencyMetrics(); metrics.ConsistencyRating != "N/A" {
+		t.Errorf("Expected N/A consistency for two scores, got %s", metrics.ConsistencyRating)
+	}
+}
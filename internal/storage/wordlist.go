@@ -2,11 +2,10 @@ package storage
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"unicode/utf8"
 )
 
 // WordList represents a custom word list
@@ -19,27 +18,16 @@ type WordList struct {
 
 // WordListManager manages custom word lists
 type WordListManager struct {
-	Lists []WordList `json:"lists"`
-	path  string
+	Version int        `json:"version"`
+	Lists   []WordList `json:"lists"`
+	path    string
 }
 
 // NewWordListManager creates or loads a word list manager
-func NewWordListManager() *WordListManager {
+func NewWordListManager(root Root) *WordListManager {
 	wm := &WordListManager{
 		Lists: []WordList{},
-	}
-
-	// Get config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		configDir = os.TempDir()
-	}
-
-	ktypeDir := filepath.Join(configDir, "ktype")
-	if err := os.MkdirAll(ktypeDir, 0755); err != nil {
-		wm.path = "wordlists.json"
-	} else {
-		wm.path = filepath.Join(ktypeDir, "wordlists.json")
+		path:  root.configPath("wordlists.json"),
 	}
 
 	wm.load()
@@ -48,25 +36,30 @@ func NewWordListManager() *WordListManager {
 
 // load reads word lists from file
 func (wm *WordListManager) load() {
-	data, err := os.ReadFile(wm.path)
-	if err != nil {
-		return // File doesn't exist yet
-	}
-
-	if err := json.Unmarshal(data, wm); err != nil {
-		// Corrupt file - start fresh
+	if err := loadJSON(wm.path, wm, wordListsSchema); err != nil {
+		// Corrupt file without a backup - start fresh
 		wm.Lists = []WordList{}
 	}
 }
 
 // save writes word lists to file
 func (wm *WordListManager) save() error {
-	data, err := json.MarshalIndent(wm, "", "  ")
-	if err != nil {
-		return err
-	}
+	wm.Version = wordListsSchema.version()
+	return saveJSON(wm.path, wm)
+}
 
-	return os.WriteFile(wm.path, data, 0644)
+// update applies a change to the word lists on disk under the file lock, so
+// lists added by other running instances are kept. Nothing is written if the
+// change fails.
+func (wm *WordListManager) update(apply func() error) error {
+	return withLock(wm.path, func() error {
+		wm.Lists = []WordList{}
+		wm.load()
+		if err := apply(); err != nil {
+			return err
+		}
+		return wm.save()
+	})
 }
 
 // AddList adds a new word list
@@ -76,13 +69,6 @@ func (wm *WordListManager) AddList(name, description string, words []string) err
 		return fmt.Errorf("word list name cannot be empty")
 	}
 
-	// Check for duplicates
-	for _, list := range wm.Lists {
-		if list.Name == name {
-			return fmt.Errorf("word list '%s' already exists", name)
-		}
-	}
-
 	// Clean and validate words
 	cleanWords := cleanWordList(words)
 	if len(cleanWords) == 0 {
@@ -96,19 +82,28 @@ func (wm *WordListManager) AddList(name, description string, words []string) err
 		CreatedAt:   fmt.Sprintf("%d", os.Getpid()), // Simple timestamp
 	}
 
-	wm.Lists = append(wm.Lists, list)
-	return wm.save()
+	return wm.update(func() error {
+		// Check for duplicates
+		if wm.GetList(name) != nil {
+			return fmt.Errorf("word list '%s' already exists", name)
+		}
+
+		wm.Lists = append(wm.Lists, list)
+		return nil
+	})
 }
 
 // DeleteList removes a word list by name
 func (wm *WordListManager) DeleteList(name string) error {
-	for i, list := range wm.Lists {
-		if list.Name == name {
-			wm.Lists = append(wm.Lists[:i], wm.Lists[i+1:]...)
-			return wm.save()
+	return wm.update(func() error {
+		for i, list := range wm.Lists {
+			if list.Name == name {
+				wm.Lists = append(wm.Lists[:i], wm.Lists[i+1:]...)
+				return nil
+			}
 		}
-	}
-	return fmt.Errorf("word list '%s' not found", name)
+		return fmt.Errorf("word list '%s' not found", name)
+	})
 }
 
 // GetList returns a word list by name
@@ -204,8 +199,10 @@ func (wm *WordListManager) Count() int {
 
 // Clear removes all custom word lists
 func (wm *WordListManager) Clear() error {
-	wm.Lists = []WordList{}
-	return wm.save()
+	return wm.update(func() error {
+		wm.Lists = []WordList{}
+		return nil
+	})
 }
 
 // cleanWordList cleans and validates a word list
@@ -243,7 +240,7 @@ func ValidateWordListName(name string) error {
 		return fmt.Errorf("name cannot be empty")
 	}
 
-	if len(name) > 50 {
+	if utf8.RuneCountInString(name) > 50 {
 		return fmt.Errorf("name too long (max 50 characters)")
 	}
 
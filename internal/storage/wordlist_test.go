@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewWordListManager(t *testing.T) {
-	wm := NewWordListManager()
+	wm := NewWordListManager(NewRoot(t.TempDir()))
 	if wm == nil {
 		t.Fatal("NewWordListManager returned nil")
 	}
@@ -341,3 +341,27 @@ func TestWordListManagerExportToFile(t *testing.T) {
 		t.Error("Exported file should contain 'apple'")
 	}
 }
+
+func TestWordListManagerConcurrentInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wordlists.json")
+	wm1 := &WordListManager{Lists: []WordList{}, path: path}
+	wm2 := &WordListManager{Lists: []WordList{}, path: path}
+
+	if err := wm1.AddList("first", "", []string{"alpha"}); err != nil {
+		t.Fatalf("AddList failed: %v", err)
+	}
+	if err := wm2.AddList("second", "", []string{"beta"}); err != nil {
+		t.Fatalf("AddList failed: %v", err)
+	}
+
+	// The second instance sees the first list, so duplicates are still caught
+	if err := wm2.AddList("first", "", []string{"gamma"}); err == nil {
+		t.Error("Expected a duplicate error for a list added by another instance")
+	}
+
+	loaded := &WordListManager{Lists: []WordList{}, path: path}
+	loaded.load()
+	if loaded.Count() != 2 {
+		t.Errorf("Expected both lists on disk, got %v", loaded.ListNames())
+	}
+}
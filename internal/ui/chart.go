@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"ktype/internal/game"
+)
+
+// brailleDots maps a dot position (column, row) inside a braille cell to its bit
+var brailleDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// brailleGrid is a canvas of braille cells, each holding 2x4 dots
+type brailleGrid struct {
+	width, height int // In cells
+	cells         [][]rune
+}
+
+func newBrailleGrid(width, height int) *brailleGrid {
+	cells := make([][]rune, height)
+	for i := range cells {
+		cells[i] = make([]rune, width)
+	}
+	return &brailleGrid{width: width, height: height, cells: cells}
+}
+
+// set turns on the dot at x, y, with y = 0 at the bottom
+func (b *brailleGrid) set(x, y int) {
+	row := b.height*4 - 1 - y
+	if x < 0 || row < 0 || x >= b.width*2 || row >= b.height*4 {
+		return
+	}
+	b.cells[row/4][x/2] |= brailleDots[x%2][row%4]
+}
+
+// plot draws values as a connected line across the full width of the grid
+func (b *brailleGrid) plot(values []float64, maxValue float64) {
+	dotsX, dotsY := b.width*2, b.height*4
+	prev := -1
+	for x := 0; x < dotsX; x++ {
+		// Interpolate between the two samples around this column
+		pos := float64(x) * float64(len(values)-1) / float64(dotsX-1)
+		i := int(pos)
+		v := values[i]
+		if i+1 < len(values) {
+			v += (values[i+1] - v) * (pos - float64(i))
+		}
+
+		y := int(math.Round(v / maxValue * float64(dotsY-1)))
+		from, to := y, y
+		if prev >= 0 {
+			from, to = min(prev, y), max(prev, y)
+		}
+		for dy := from; dy <= to; dy++ {
+			b.set(x, dy)
+		}
+		prev = y
+	}
+}
+
+// renderTimelineChart draws the WPM and raw WPM of a test over time as a braille
+// line chart, with the seconds in which errors were made marked underneath
+func renderTimelineChart(samples []game.Sample, width, height int) string {
+	if len(samples) < 2 {
+		return ""
+	}
+
+	wpm := make([]float64, len(samples))
+	raw := make([]float64, len(samples))
+	maxValue := 10.0
+	for i, s := range samples {
+		wpm[i] = s.WPM
+		raw[i] = s.RawWPM
+		maxValue = max(maxValue, s.WPM, s.RawWPM)
+	}
+	maxValue = math.Ceil(maxValue/10) * 10
+
+	labelWidth := len(fmt.Sprintf("%d", int(maxValue))) + 1
+	chartWidth := width - labelWidth
+
+	rawGrid := newBrailleGrid(chartWidth, height)
+	rawGrid.plot(raw, maxValue)
+	wpmGrid := newBrailleGrid(chartWidth, height)
+	wpmGrid.plot(wpm, maxValue)
+
+	var s strings.Builder
+	for row := 0; row < height; row++ {
+		label := ""
+		switch row {
+		case 0:
+			label = fmt.Sprintf("%d", int(maxValue))
+		case height - 1:
+			label = "0"
+		}
+		s.WriteString(subtleStyle.Render(fmt.Sprintf("%*s ", labelWidth-1, label)))
+
+		for col := 0; col < chartWidth; col++ {
+			wpmDots, rawDots := wpmGrid.cells[row][col], rawGrid.cells[row][col]
+			switch {
+			case wpmDots != 0:
+				s.WriteString(wpmStyle.Render(string(0x2800 + (wpmDots | rawDots))))
+			case rawDots != 0:
+				s.WriteString(subtleStyle.Render(string(0x2800 + rawDots)))
+			default:
+				s.WriteString(" ")
+			}
+		}
+		s.WriteString("\n")
+	}
+
+	// Error markers, one per second with mistakes
+	marks := []rune(strings.Repeat(" ", chartWidth))
+	for i, sample := range samples {
+		if sample.Errors > 0 {
+			marks[i*(chartWidth-1)/(len(samples)-1)] = '×'
+		}
+	}
+	s.WriteString(strings.Repeat(" ", labelWidth))
+	s.WriteString(errorDetailStyle.Render(string(marks)))
+	s.WriteString("\n")
+
+	s.WriteString(strings.Repeat(" ", labelWidth))
+	s.WriteString(subtleStyle.Render(fmt.Sprintf("%-*s", chartWidth-4, "1s")))
+	s.WriteString(subtleStyle.Render(fmt.Sprintf("%4s", fmt.Sprintf("%ds", samples[len(samples)-1].Second))))
+	s.WriteString("\n")
+	s.WriteString(strings.Repeat(" ", labelWidth))
+	s.WriteString(wpmStyle.Render("⠒ wpm") + "  " + subtleStyle.Render("⠒ raw") + "  " + errorDetailStyle.Render("× errors"))
+
+	return s.String()
+}
@@ -4,14 +4,72 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 	"ktype/internal/game"
+	"ktype/internal/keyboard"
+	"ktype/internal/storage"
+	"ktype/internal/words"
 )
 
 // RenderGame renders the main game screen
 func RenderGame(g *game.Game, width, height int, wantToQuit bool, cursorChar string) string {
 	var s strings.Builder
+	internalWidth := 57
+
+	s.WriteString(renderGameBody(g, cursorChar))
+
+	// Help text or quit confirmation
+	s.WriteString("\n\n")
+	var help string
+	if wantToQuit {
+		help = errorStyle.Render("press esc again to quit")
+	} else {
+		help = helpStyle.Render("tab: restart • esc: abort")
+	}
+	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, help))
+
+	content := containerStyle.Render(s.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// RenderReplay renders a replay of a recorded test with playback controls
+func RenderReplay(r *game.Replay, width, height int, cursorChar string) string {
+	var s strings.Builder
+	internalWidth := 57
+
+	s.WriteString(renderGameBody(r.Game, cursorChar))
+	s.WriteString("\n\n")
+
+	state := "▶"
+	if r.Paused {
+		state = "❚❚"
+	}
+	playback := timerStyle.Render(state) + " " +
+		renderBar(int(r.Position.Milliseconds()), int(r.Total().Milliseconds()), 20) + " " +
+		subtleStyle.Render(fmt.Sprintf("%s / %s • %gx", formatClock(r.Position), formatClock(r.Total()), r.Speed))
+	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, playback))
+
+	s.WriteString("\n\n")
+	help := helpStyle.Render("space: pause • ←/→: seek • -/+: speed • esc: back")
+	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, help))
+
+	content := containerStyle.Render(s.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// formatClock formats a duration as m:ss
+func formatClock(d time.Duration) string {
+	secs := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// renderGameBody renders the words, progress and live stats of a game
+func renderGameBody(g *game.Game, cursorChar string) string {
+	var s strings.Builder
 
 	// Inside the container, we have Width(65) and Padding(2, 4).
 	// So internal width is 65 - 4*2 = 57.
@@ -30,6 +88,12 @@ func RenderGame(g *game.Game, width, height int, wantToQuit bool, cursorChar str
 
 	// Progress (timer or word count)
 	progress := timerStyle.Render(g.Progress())
+	if g.Strictness != storage.StrictOff {
+		progress += subtleStyle.Render("  stop on " + g.Strictness.String())
+	}
+	if g.Emulation != nil {
+		progress += subtleStyle.Render("  " + g.Emulation.Layout.Name + " (emulated)")
+	}
 	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, progress))
 	s.WriteString("\n\n")
 
@@ -46,6 +110,11 @@ func RenderGame(g *game.Game, width, height int, wantToQuit bool, cursorChar str
 	stats := statsStyle.Render(wpm + subtleStyle.Render("  •  ") + accuracy + subtleStyle.Render("  •  ") + liveErrorDisplay + subtleStyle.Render(" errors"))
 	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, stats))
 
+	if g.Ghost != nil {
+		s.WriteString("\n")
+		s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, renderGhostLead(g)))
+	}
+
 	// Error statistics section (subtle)
 	totalErrors, topErrors := GetErrorStats(g)
 	if totalErrors > 0 {
@@ -67,22 +136,12 @@ func RenderGame(g *game.Game, width, height int, wantToQuit bool, cursorChar str
 		s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, errorStats.String()))
 	}
 
-	// Help text or quit confirmation
-	s.WriteString("\n\n")
-	var help string
-	if wantToQuit {
-		help = errorStyle.Render("press esc again to quit")
-	} else {
-		help = helpStyle.Render("tab: restart • esc: abort")
-	}
-	s.WriteString(lipgloss.PlaceHorizontal(internalWidth, lipgloss.Center, help))
-
-	content := containerStyle.Render(s.String())
-	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+	return s.String()
 }
 
-// RenderFinished renders the end screen
-func RenderFinished(g *game.Game, width, height int, isPB bool, wantToQuit bool) string {
+// RenderFinished renders the end screen. layout is the keyboard the test's
+// keystrokes are broken down by finger and hand on.
+func RenderFinished(g *game.Game, layout keyboard.Layout, width, height int, isPB bool, wantToQuit bool) string {
 	var s strings.Builder
 
 	if isPB {
@@ -108,6 +167,7 @@ func RenderFinished(g *game.Game, width, height int, isPB bool, wantToQuit bool)
 	stats := []string{
 		subtleStyle.Render("accuracy: ") + accuracyStyle.Render(fmt.Sprintf("%d%%", g.Accuracy())),
 		subtleStyle.Render("raw wpm: ") + statsStyle.Render(fmt.Sprintf("%d", g.RawWPM())),
+		subtleStyle.Render("consistency: ") + statsStyle.Render(fmt.Sprintf("%.0f%%", g.Consistency())),
 		subtleStyle.Render("correct: ") + statsStyle.Render(fmt.Sprintf("%d/%d words", g.CorrectWordsCount(), len(g.TypedWords))),
 		subtleStyle.Render("mode: ") + statsStyle.Render(g.ModeString()),
 	}
@@ -115,13 +175,50 @@ func RenderFinished(g *game.Game, width, height int, isPB bool, wantToQuit bool)
 	for _, stat := range stats {
 		s.WriteString(stat + "\n")
 	}
+	s.WriteString(subtleStyle.Render("errors: ") + renderErrorBreakdown(g) + "\n")
+
+	if g.Adaptive != nil {
+		s.WriteString(subtleStyle.Render("practised: ") + renderTargets(g.Adaptive.Targets) + "\n")
+	}
+
+	if g.Emulation != nil {
+		s.WriteString(subtleStyle.Render("layout: ") + statsStyle.Render(g.Emulation.Layout.Name) +
+			subtleStyle.Render(" (emulated on a qwerty keymap)") + "\n")
+	}
+
+	if g.Ghost != nil {
+		s.WriteString(renderGhostLead(g) + "\n")
+	}
+
+	if chart := renderTimelineChart(g.Timeline, 57, 5); chart != "" {
+		s.WriteString("\n")
+		s.WriteString(chart)
+		s.WriteString("\n")
+	}
+
+	// Fingers and hands, for this test only
+	if breakdown := g.KeyLog().FingerBreakdown(layout); breakdown.Hands[0].Hits+breakdown.Hands[1].Hits > 0 {
+		s.WriteString("\n")
+		s.WriteString(subtleStyle.Render("by hand:"))
+		s.WriteString("\n")
+		s.WriteString(renderFingerStats(breakdown.Hands))
+		s.WriteString(subtleStyle.Render("by finger:"))
+		s.WriteString("\n")
+		s.WriteString(renderFingerStats(breakdown.Fingers))
+	}
+
+	if g.Quote != nil {
+		s.WriteString("\n")
+		s.WriteString(errorDetailStyle.Render("— " + g.Quote.Source))
+		s.WriteString("\n")
+	}
 
 	s.WriteString("\n")
 	var help string
 	if wantToQuit {
 		help = errorStyle.Render("press esc again to quit")
 	} else {
-		help = helpStyle.Render("tab to restart • esc to quit")
+		help = helpStyle.Render("tab to restart • r to replay • esc to quit")
 	}
 	s.WriteString(help)
 
@@ -136,12 +233,14 @@ func buildWordsLines(g *game.Game, maxWidth int, numLines int, cursorChar string
 		endIdx   int
 	}
 
+	ghostWord, ghostChar, hasGhost := g.GhostPosition()
+
 	lines := []lineInfo{}
 	currentLineStart := 0
 	currentLineWidth := 0
 
 	for i := 0; i < len(g.Words); i++ {
-		wordWidth := len(g.Words[i])
+		wordWidth := runewidth.StringWidth(g.Words[i])
 		spaceNeeded := wordWidth
 		if currentLineWidth > 0 {
 			spaceNeeded += 1
@@ -194,22 +293,29 @@ func buildWordsLines(g *game.Game, maxWidth int, numLines int, cursorChar string
 			word := g.Words[wordIdx]
 			rawWords = append(rawWords, word)
 
+			ghostAt := -1
+			if hasGhost && wordIdx == ghostWord {
+				ghostAt = ghostChar
+			}
+
 			if wordIdx < g.WordIndex {
 				if wordIdx < len(g.Correct) && g.Correct[wordIdx] {
-					parts = append(parts, correctStyle.Render(word))
+					parts = append(parts, renderWithGhost(word, ghostAt, correctStyle))
 				} else {
-					parts = append(parts, errorStyle.Render(word))
+					parts = append(parts, renderWithGhost(word, ghostAt, errorStyle))
 				}
 			} else if wordIdx == g.WordIndex {
 				correct, errors, remaining := g.CurrentWordState()
 				cursor := cursorStyle.Render(cursorChar)
+				// The ghost is only drawn in the part of the word not typed yet
+				remainingGhost := ghostAt - (utf8.RuneCountInString(word) - utf8.RuneCountInString(remaining))
 				currentWord := correctStyle.Render(correct) +
 					errorStyle.Render(errors) +
 					cursor +
-					currentStyle.Render(remaining)
+					renderWithGhost(remaining, remainingGhost, currentStyle)
 				parts = append(parts, currentWord)
 			} else {
-				parts = append(parts, upcomingStyle.Render(word))
+				parts = append(parts, renderWithGhost(word, ghostAt, upcomingStyle))
 			}
 		}
 
@@ -224,6 +330,59 @@ func buildWordsLines(g *game.Game, maxWidth int, numLines int, cursorChar string
 	return result
 }
 
+// renderErrorBreakdown lists the uncorrected errors by type, followed by the corrected ones
+func renderErrorBreakdown(g *game.Game) string {
+	counts := g.ErrorsByType()
+	var parts []string
+	for _, errorType := range []game.ErrorType{
+		game.ErrorWrongChar,
+		game.ErrorExtraChar,
+		game.ErrorMissingChar,
+		game.ErrorTransposition,
+	} {
+		if counts[errorType] > 0 {
+			parts = append(parts, errorStyle.Render(fmt.Sprintf("%d", counts[errorType]))+
+				subtleStyle.Render(" "+errorType.String()))
+		}
+	}
+	if corrected := g.CorrectedErrors(); corrected > 0 {
+		parts = append(parts, statsStyle.Render(fmt.Sprintf("%d", corrected))+
+			subtleStyle.Render(" corrected"))
+	}
+	if len(parts) == 0 {
+		return accuracyStyle.Render("none")
+	}
+	return strings.Join(parts, subtleStyle.Render(" • "))
+}
+
+// renderWithGhost renders a word with the ghost caret highlighting the character at ghostAt (-1 for none)
+func renderWithGhost(word string, ghostAt int, style lipgloss.Style) string {
+	runes := []rune(word)
+	if ghostAt < 0 || ghostAt >= len(runes) {
+		return style.Render(word)
+	}
+	return style.Render(string(runes[:ghostAt])) +
+		ghostStyle.Render(string(runes[ghostAt])) +
+		style.Render(string(runes[ghostAt+1:]))
+}
+
+// renderGhostLead describes how far ahead of or behind the ghost the player is
+func renderGhostLead(g *game.Game) string {
+	lead, ghostWPM := g.GhostLead()
+	label := subtleStyle.Render("ghost (" + g.Ghost.Label + "): ")
+	switch {
+	case lead > 0:
+		return label + accuracyStyle.Render(fmt.Sprintf("ahead by %d chars", lead)) +
+			subtleStyle.Render(fmt.Sprintf(" • ghost %d wpm", ghostWPM))
+	case lead < 0:
+		return label + errorStyle.Render(fmt.Sprintf("behind by %d chars", -lead)) +
+			subtleStyle.Render(fmt.Sprintf(" • ghost %d wpm", ghostWPM))
+	default:
+		return label + statsStyle.Render("level") +
+			subtleStyle.Render(fmt.Sprintf(" • ghost %d wpm", ghostWPM))
+	}
+}
+
 // justifyLine distributes extra spaces between words to fill maxWidth
 func justifyLine(styledParts []string, rawWords []string, maxWidth int) string {
 	if len(styledParts) <= 1 {
@@ -232,7 +391,7 @@ func justifyLine(styledParts []string, rawWords []string, maxWidth int) string {
 
 	totalWordLen := 0
 	for _, w := range rawWords {
-		totalWordLen += len(w)
+		totalWordLen += runewidth.StringWidth(w)
 	}
 	totalWordLen += 1 // cursor
 
@@ -260,17 +419,25 @@ func justifyLine(styledParts []string, rawWords []string, maxWidth int) string {
 	return result.String()
 }
 
-// GetErrorStats returns statistics about errors in the current game
+// GetErrorStats returns statistics about the mistakes left in the current
+// game. Mistakes fixed with backspace don't count.
 func GetErrorStats(g *game.Game) (totalErrors int, topErrors []struct {
 	Char  rune
 	Count int
 }) {
-	totalErrors = len(g.Errors)
-
-	// Count errors by character
+	// Count errors by character. A skipped character has nothing typed, so it
+	// is counted under the character that was missed.
 	charCounts := make(map[rune]int)
 	for _, err := range g.Errors {
-		charCounts[err.TypedChar]++
+		if err.Corrected {
+			continue
+		}
+		totalErrors++
+		char := err.TypedChar
+		if err.ErrorType == game.ErrorMissingChar {
+			char = err.ExpectedChar
+		}
+		charCounts[char]++
 	}
 
 	// Convert to slice for sorting
@@ -286,7 +453,10 @@ func GetErrorStats(g *game.Game) (totalErrors int, topErrors []struct {
 
 	// Sort by count descending
 	sort.Slice(counts, func(i, j int) bool {
-		return counts[i].Count > counts[j].Count
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Char < counts[j].Char
 	})
 
 	// Return top 3
@@ -308,3 +478,16 @@ func GetErrorStats(g *game.Game) (totalErrors int, topErrors []struct {
 
 	return totalErrors, result
 }
+
+// renderTargets lists the strongest adaptive practice targets
+func renderTargets(targets []words.Target) string {
+	if len(targets) == 0 {
+		return statsStyle.Render("no weak spots yet - all words equally likely")
+	}
+
+	var names []string
+	for _, t := range targets[:min(6, len(targets))] {
+		names = append(names, t.Text)
+	}
+	return statsStyle.Render(strings.Join(names, " "))
+}
@@ -9,22 +9,34 @@ import (
 	"ktype/internal/words"
 )
 
+// PBLookup returns the personal best for a mode such as "time:30" under the
+// current settings, or nil if there is none
+type PBLookup func(mode string) *storage.Score
+
 // RenderMainMenu renders the main menu with quick start options
-func RenderMainMenu(lb *storage.Leaderboard, width, height int, wantToQuit bool, difficulty words.Difficulty, complexity words.Complexity) string {
+func RenderMainMenu(findPB PBLookup, width, height int, wantToQuit bool, difficulty words.Difficulty, complexity words.Complexity, wordList string, order words.Order, notices []string) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("ktype")
 	s.WriteString(title)
 	s.WriteString("\n\n")
 
+	for _, notice := range notices {
+		s.WriteString(noticeStyle.Render("⚠ " + notice))
+		s.WriteString("\n")
+	}
+	if len(notices) > 0 {
+		s.WriteString("\n")
+	}
+
 	// Quick Start Presets
 	s.WriteString(subtleStyle.Render("quick start:"))
 	s.WriteString("\n\n")
 
 	// Get PBs for presets
-	pb30s := lb.GetPB("time:30")
-	pb50w := lb.GetPB("words:50")
-	pbZen := lb.GetPB("zen")
+	pb30s := findPB("time:30")
+	pb50w := findPB("words:50")
+	pbZen := findPB("zen")
 
 	formatPB := func(s *storage.Score) string {
 		if s == nil {
@@ -51,6 +63,8 @@ func RenderMainMenu(lb *storage.Leaderboard, width, height int, wantToQuit bool,
 	moreModes := []string{
 		wpmStyle.Render("t") + subtleStyle.Render(" → timed modes selection"),
 		wpmStyle.Render("w") + subtleStyle.Render(" → words modes selection"),
+		wpmStyle.Render("q") + subtleStyle.Render(" → quote mode"),
+		wpmStyle.Render("a") + subtleStyle.Render(" → adaptive practice (your weakest keys)"),
 	}
 
 	for _, opt := range moreModes {
@@ -83,7 +97,12 @@ func RenderMainMenu(lb *storage.Leaderboard, width, height int, wantToQuit bool,
 
 	// Custom Word Lists
 	s.WriteString("\n")
-	s.WriteString(subtleStyle.Render("word lists:"))
+	if wordList != "" {
+		s.WriteString(subtleStyle.Render("word list: ") + wpmStyle.Render(wordList) +
+			subtleStyle.Render(" ("+order.String()+")"))
+	} else {
+		s.WriteString(subtleStyle.Render("word lists:"))
+	}
 	s.WriteString("\n")
 	s.WriteString("   " + wpmStyle.Render("l") + subtleStyle.Render(" → custom word lists\n"))
 
@@ -114,7 +133,7 @@ func RenderMainMenu(lb *storage.Leaderboard, width, height int, wantToQuit bool,
 }
 
 // RenderTimeSelect renders the time duration selection screen with PBs
-func RenderTimeSelect(lb *storage.Leaderboard, width, height int, wantToQuit bool) string {
+func RenderTimeSelect(findPB PBLookup, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("timed mode")
@@ -135,7 +154,7 @@ func RenderTimeSelect(lb *storage.Leaderboard, width, height int, wantToQuit boo
 	}
 
 	for _, d := range durations {
-		pb := lb.GetPB(d.mode)
+		pb := findPB(d.mode)
 		pbText := ""
 		if pb != nil {
 			pbText = pbStyle.Render(fmt.Sprintf(" (PB: %d|%d%%)", pb.WPM, pb.Accuracy))
@@ -158,7 +177,7 @@ func RenderTimeSelect(lb *storage.Leaderboard, width, height int, wantToQuit boo
 }
 
 // RenderWordsSelect renders the word count selection screen with PBs
-func RenderWordsSelect(lb *storage.Leaderboard, width, height int, wantToQuit bool) string {
+func RenderWordsSelect(findPB PBLookup, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("words mode")
@@ -180,7 +199,7 @@ func RenderWordsSelect(lb *storage.Leaderboard, width, height int, wantToQuit bo
 	}
 
 	for _, c := range counts {
-		pb := lb.GetPB(c.mode)
+		pb := findPB(c.mode)
 		pbText := ""
 		if pb != nil {
 			pbText = pbStyle.Render(fmt.Sprintf(" (PB: %d|%d%%)", pb.WPM, pb.Accuracy))
@@ -202,6 +221,50 @@ func RenderWordsSelect(lb *storage.Leaderboard, width, height int, wantToQuit bo
 	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// RenderQuoteSelect renders the quote length selection screen with PBs
+func RenderQuoteSelect(findPB PBLookup, width, height int, wantToQuit bool) string {
+	var s strings.Builder
+
+	title := titleStyle.Render("quote mode")
+	s.WriteString(title)
+	s.WriteString("\n\n")
+
+	s.WriteString(subtleStyle.Render("select quote length:"))
+	s.WriteString("\n\n")
+
+	lengths := []struct {
+		key    string
+		length words.QuoteLength
+		desc   string
+	}{
+		{"1", words.QuoteShort, "up to 100 chars"},
+		{"2", words.QuoteMedium, "101-300 chars"},
+		{"3", words.QuoteLong, "301-600 chars"},
+		{"4", words.QuoteGrind, "600+ chars"},
+	}
+
+	for _, l := range lengths {
+		pb := findPB("quote:" + l.length.String())
+		pbText := ""
+		if pb != nil {
+			pbText = pbStyle.Render(fmt.Sprintf(" (PB: %d|%d%%)", pb.WPM, pb.Accuracy))
+		}
+		s.WriteString(fmt.Sprintf("   %s %s (%s)%s\n", wpmStyle.Render(l.key), subtleStyle.Render("→ "+l.length.String()), l.desc, pbText))
+	}
+
+	s.WriteString("\n")
+	var help string
+	if wantToQuit {
+		help = errorStyle.Render("press esc again to quit")
+	} else {
+		help = helpStyle.Render("esc: back")
+	}
+	s.WriteString(help)
+
+	content := containerStyle.Render(s.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
 // RenderDifficultySelect renders the difficulty selection screen
 func RenderDifficultySelect(currentDifficulty words.Difficulty, width, height int, wantToQuit bool) string {
 	var s strings.Builder
@@ -319,8 +382,9 @@ func RenderCustomInput(input, mode string, width, height int, cursorChar string)
 	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
 }
 
-// RenderSettings renders the settings menu
-func RenderSettings(cm *storage.ConfigManager, width, height int, wantToQuit bool) string {
+// RenderSettings renders the settings menu. status reports the outcome of the
+// last action, such as a backup.
+func RenderSettings(cm *storage.ConfigManager, status string, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("settings")
@@ -336,6 +400,34 @@ func RenderSettings(cm *storage.ConfigManager, width, height int, wantToQuit boo
 	s.WriteString("   " + wpmStyle.Render("2") + subtleStyle.Render(" → accent color: ") +
 		lipgloss.NewStyle().Foreground(lipgloss.Color(cm.GetAccentColorHex())).
 			Bold(true).Render(cm.GetAccentColorName()))
+	s.WriteString("\n")
+	keyLogs := "off"
+	if cm.GetConfig().SaveKeyLogs {
+		keyLogs = "on"
+	}
+	s.WriteString("   " + wpmStyle.Render("3") + subtleStyle.Render(" → save keystroke logs: ") +
+		accuracyStyle.Render(keyLogs))
+	s.WriteString("\n")
+	s.WriteString("   " + wpmStyle.Render("4") + subtleStyle.Render(" → ghost: ") +
+		accuracyStyle.Render(cm.GetConfig().Ghost.String()))
+	s.WriteString("\n")
+	s.WriteString("   " + wpmStyle.Render("5") + subtleStyle.Render(" → ghost pace: ") +
+		accuracyStyle.Render(fmt.Sprintf("%d wpm", cm.GetConfig().GhostWPM)))
+	s.WriteString("\n")
+	s.WriteString("   " + wpmStyle.Render("6") + subtleStyle.Render(" → stop on error: ") +
+		accuracyStyle.Render(cm.GetConfig().Strictness.String()))
+	s.WriteString("\n")
+	s.WriteString("   " + wpmStyle.Render("7") + subtleStyle.Render(" → back up profile"))
+	s.WriteString("\n")
+	s.WriteString("   " + wpmStyle.Render("8") + subtleStyle.Render(" → keyboard layout: ") +
+		accuracyStyle.Render(cm.GetLayout().Name))
+	s.WriteString("\n")
+	emulate := "off"
+	if cm.GetConfig().Emulate {
+		emulate = "on"
+	}
+	s.WriteString("   " + wpmStyle.Render("9") + subtleStyle.Render(" → emulate layout on qwerty: ") +
+		accuracyStyle.Render(emulate))
 	s.WriteString("\n\n")
 
 	s.WriteString(subtleStyle.Render("current settings:"))
@@ -343,6 +435,12 @@ func RenderSettings(cm *storage.ConfigManager, width, height int, wantToQuit boo
 	s.WriteString(fmt.Sprintf("   cursor: %s\n", cm.GetCursorTypeName()))
 	s.WriteString(fmt.Sprintf("   color:  %s (%s)\n", cm.GetAccentColorName(), cm.GetAccentColorHex()))
 
+	if status != "" {
+		s.WriteString("\n")
+		s.WriteString(subtleStyle.Render(status))
+		s.WriteString("\n")
+	}
+
 	s.WriteString("\n")
 	var help string
 	if wantToQuit {
@@ -456,7 +554,7 @@ func RenderColorSelect(cm *storage.ConfigManager, width, height int, wantToQuit
 }
 
 // RenderCustomWordList renders the custom word list management screen
-func RenderCustomWordList(wm *storage.WordListManager, currentList string, width, height int, wantToQuit bool) string {
+func RenderCustomWordList(wm *storage.WordListManager, currentList string, order words.Order, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("custom word lists")
@@ -493,12 +591,15 @@ func RenderCustomWordList(wm *storage.WordListManager, currentList string, width
 		s.WriteString("\n")
 		if currentList != "" {
 			s.WriteString(accuracyStyle.Render(fmt.Sprintf("selected: %s", currentList)))
+			s.WriteString("\n")
+			s.WriteString(subtleStyle.Render("tests from the menu now use this list"))
 			s.WriteString("\n\n")
 		}
 
 		s.WriteString(subtleStyle.Render("actions:"))
 		s.WriteString("\n")
 		s.WriteString("   " + wpmStyle.Render("1-9") + subtleStyle.Render(" → select word list\n"))
+		s.WriteString("   " + wpmStyle.Render("o") + subtleStyle.Render(" → word order: ") + accuracyStyle.Render(order.String()) + "\n")
 		s.WriteString("   " + wpmStyle.Render("d") + subtleStyle.Render(" → delete selected\n"))
 		s.WriteString("   " + wpmStyle.Render("c") + subtleStyle.Render(" → clear selection\n"))
 	}
@@ -5,21 +5,31 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"ktype/internal/keyboard"
 	"ktype/internal/storage"
 )
 
-// RenderStats renders the statistics dashboard
-func RenderStats(stats *storage.Statistics, width, height int, wantToQuit bool) string {
+// RenderStats renders the statistics dashboard. stats is already narrowed to
+// filter and window, which are shown so the numbers can be read in context.
+// fingers is the all-time breakdown by finger and hand, which the filter
+// can't narrow.
+func RenderStats(stats *storage.Statistics, fingers storage.FingerBreakdown, filter storage.ScoreFilter, window storage.TimeWindow, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("statistics")
 	s.WriteString(title)
+	s.WriteString("\n")
+	s.WriteString(renderStatsFilter(filter, window))
 	s.WriteString("\n\n")
 
 	// Summary statistics
 	summary := stats.GetSummary()
 	if summary.TotalTests == 0 {
-		s.WriteString(subtleStyle.Render("no data yet - complete some tests to see statistics"))
+		if filter == (storage.ScoreFilter{}) && window == storage.WindowAll {
+			s.WriteString(subtleStyle.Render("no data yet - complete some tests to see statistics"))
+		} else {
+			s.WriteString(subtleStyle.Render("no tests match these filters"))
+		}
 		s.WriteString("\n\n")
 	} else {
 		// Overall stats
@@ -87,6 +97,14 @@ func RenderStats(stats *storage.Statistics, width, height int, wantToQuit bool)
 			s.WriteString("\n")
 		}
 
+		// Hands, from the heatmap's keystrokes: the filter doesn't apply
+		if fingers.Hands[0].Hits+fingers.Hands[1].Hits > 0 {
+			s.WriteString(subtleStyle.Render("by hand (all-time, " + fingers.Layout + ", ignores the filter):"))
+			s.WriteString("\n")
+			s.WriteString(renderFingerStats(fingers.Hands))
+			s.WriteString("\n")
+		}
+
 		// WPM Distribution
 		distribution := stats.GetWPMDistribution()
 		s.WriteString(subtleStyle.Render("performance distribution:"))
@@ -107,7 +125,7 @@ func RenderStats(stats *storage.Statistics, width, height int, wantToQuit bool)
 	if wantToQuit {
 		help = errorStyle.Render("press esc again to go back")
 	} else {
-		help = helpStyle.Render("esc to go back")
+		help = helpStyle.Render("esc to go back • r: recent tests • n: n-grams • m/d/c/w: filter")
 	}
 	s.WriteString(help)
 
@@ -115,8 +133,95 @@ func RenderStats(stats *storage.Statistics, width, height int, wantToQuit bool)
 	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
 }
 
-// RenderHeatmap renders the typing heatmap visualization
-func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) string {
+// renderStatsFilter shows which tests the statistics cover, with the key
+// that changes each filter
+func renderStatsFilter(filter storage.ScoreFilter, window storage.TimeWindow) string {
+	value := func(v string) string {
+		if v == "" {
+			return subtleStyle.Render("all")
+		}
+		return accuracyStyle.Render(v)
+	}
+	windowValue := subtleStyle.Render(window.String())
+	if window != storage.WindowAll {
+		windowValue = accuracyStyle.Render(window.String())
+	}
+
+	return strings.Join([]string{
+		wpmStyle.Render("m") + subtleStyle.Render(" mode ") + value(filter.Mode),
+		wpmStyle.Render("d") + subtleStyle.Render(" difficulty ") + value(filter.Difficulty),
+		wpmStyle.Render("c") + subtleStyle.Render(" complexity ") + value(filter.Complexity),
+		wpmStyle.Render("w") + subtleStyle.Render(" window ") + windowValue,
+	}, subtleStyle.Render(" · "))
+}
+
+// HistoryPageSize is the number of past tests shown per page of the history
+const HistoryPageSize = 9
+
+// RenderHistory renders a page of past tests, newest first, and which ones can be replayed
+func RenderHistory(lb *storage.Leaderboard, root storage.Root, page, width, height int, wantToQuit bool) string {
+	var s strings.Builder
+
+	title := titleStyle.Render("history")
+	s.WriteString(title)
+	s.WriteString(subtleStyle.Render(fmt.Sprintf(" · %d tests", lb.Count())))
+	s.WriteString("\n\n")
+
+	recent := lb.Page(page, HistoryPageSize)
+	if len(recent) == 0 {
+		s.WriteString(subtleStyle.Render("no tests yet"))
+		s.WriteString("\n")
+	}
+
+	for i, score := range recent {
+		replay := ""
+		if root.HasKeyLog(score) {
+			replay = accuracyStyle.Render(" ▶")
+		}
+		s.WriteString(fmt.Sprintf("   %s %s %s %s %s %s%s\n",
+			wpmStyle.Render(fmt.Sprintf("%d", i+1)),
+			subtleStyle.Render(score.Date.Format("Jan 02 15:04")),
+			statsStyle.Render(fmt.Sprintf("%-10s", score.Mode)),
+			subtleStyle.Render(fmt.Sprintf("%-12s", scoreSettings(score))),
+			wpmStyle.Render(fmt.Sprintf("%3d wpm", score.WPM)),
+			accuracyStyle.Render(fmt.Sprintf("%3d%%", score.Accuracy)),
+			replay))
+	}
+
+	s.WriteString("\n")
+	var help string
+	if wantToQuit {
+		help = errorStyle.Render("press esc again to go back")
+	} else {
+		help = helpStyle.Render(fmt.Sprintf("page %d/%d • ←/→ page • 1-9 replay (▶) • esc back", page+1, lb.Pages(HistoryPageSize)))
+	}
+	s.WriteString(help)
+
+	content := containerStyle.Render(s.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// scoreSettings describes the words a score was set with in at most 12 columns,
+// e.g. "hard punct" or the name of the custom word list
+func scoreSettings(score storage.Score) string {
+	short := map[string]string{"punctuation": "punct", "numbers": "num", "full": "full"}
+
+	settings := score.Difficulty
+	if score.WordList != "" {
+		settings = score.WordList
+		if len([]rune(settings)) > 7 {
+			settings = string([]rune(settings)[:6]) + "…"
+		}
+	}
+	if c := short[score.Complexity]; c != "" {
+		settings = strings.TrimSpace(settings + " " + c)
+	}
+	return settings
+}
+
+// RenderHeatmap renders the typing heatmap visualization, drawing the keyboard
+// in the given layout
+func RenderHeatmap(hm *storage.Heatmap, layout keyboard.Layout, width, height int, wantToQuit bool) string {
 	var s strings.Builder
 
 	title := titleStyle.Render("typing heatmap")
@@ -175,14 +280,14 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 		}
 
 		// Keyboard heatmap visualization
-		s.WriteString(subtleStyle.Render("keyboard layout (error heat):"))
+		s.WriteString(subtleStyle.Render(layout.Name + " layout (error heat):"))
 		s.WriteString("\n\n")
 
-		keyboard := hm.GetHeatmapData()
+		keys := hm.GetHeatmapData(layout)
 
 		// Numbers row
 		s.WriteString(subtleStyle.Render("  numbers: "))
-		for _, stat := range keyboard.Numbers {
+		for _, stat := range keys.Numbers {
 			errorRate := stat.ErrorRate()
 			level := storage.GetErrorHeatLevel(errorRate)
 			color := storage.GetHeatColor(level)
@@ -191,9 +296,9 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 		}
 		s.WriteString("\n\n")
 
-		// Top row (QWERTY)
+		// Top row
 		s.WriteString(subtleStyle.Render("  top:     "))
-		for _, stat := range keyboard.TopRow {
+		for _, stat := range keys.TopRow {
 			errorRate := stat.ErrorRate()
 			level := storage.GetErrorHeatLevel(errorRate)
 			color := storage.GetHeatColor(level)
@@ -204,7 +309,7 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 
 		// Home row
 		s.WriteString(subtleStyle.Render("  home:    "))
-		for _, stat := range keyboard.HomeRow {
+		for _, stat := range keys.HomeRow {
 			errorRate := stat.ErrorRate()
 			level := storage.GetErrorHeatLevel(errorRate)
 			color := storage.GetHeatColor(level)
@@ -215,7 +320,7 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 
 		// Bottom row
 		s.WriteString(subtleStyle.Render("  bottom:  "))
-		for _, stat := range keyboard.BottomRow {
+		for _, stat := range keys.BottomRow {
 			errorRate := stat.ErrorRate()
 			level := storage.GetErrorHeatLevel(errorRate)
 			color := storage.GetHeatColor(level)
@@ -240,7 +345,17 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 			keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(l.color))
 			s.WriteString(keyStyle.Render("█") + subtleStyle.Render(" "+l.label+"  "))
 		}
+		s.WriteString("\n\n")
+
+		// Fingers and hands
+		breakdown := hm.GetFingerBreakdown(layout)
+		s.WriteString(subtleStyle.Render("by finger:"))
+		s.WriteString("\n")
+		s.WriteString(renderFingerStats(breakdown.Fingers))
 		s.WriteString("\n")
+		s.WriteString(subtleStyle.Render("by hand:"))
+		s.WriteString("\n")
+		s.WriteString(renderFingerStats(breakdown.Hands))
 	}
 
 	s.WriteString("\n")
@@ -256,6 +371,105 @@ func RenderHeatmap(hm *storage.Heatmap, width, height int, wantToQuit bool) stri
 	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// RenderNGrams renders the slowest and most error-prone bigrams and trigrams
+func RenderNGrams(ng *storage.NGrams, width, height int, wantToQuit bool) string {
+	var s strings.Builder
+
+	title := titleStyle.Render("n-grams")
+	s.WriteString(title)
+	s.WriteString("\n\n")
+
+	if ng.Total() == 0 {
+		s.WriteString(subtleStyle.Render("no data yet - complete some tests to see your slowest letter sequences"))
+		s.WriteString("\n\n")
+	} else {
+		s.WriteString(subtleStyle.Render(fmt.Sprintf("%d sequences typed · only those typed %d+ times are ranked",
+			ng.Total(), storage.MinNGramCount)))
+		s.WriteString("\n\n")
+
+		var slowest, errors strings.Builder
+		for _, n := range []int{2, 3} {
+			slowest.WriteString(subtleStyle.Render(fmt.Sprintf("slowest %s:", ngramName(n))))
+			slowest.WriteString("\n")
+			stats := ng.GetSlowest(n, 5)
+			for _, stat := range stats {
+				slowest.WriteString(fmt.Sprintf("   %s %s %s\n",
+					wpmStyle.Render(fmt.Sprintf("%-4s", stat.NGram)),
+					statsStyle.Render(fmt.Sprintf("%4dms", stat.AverageLatency().Milliseconds())),
+					subtleStyle.Render(fmt.Sprintf("(%d×)", stat.Count))))
+			}
+			if len(stats) == 0 {
+				slowest.WriteString(subtleStyle.Render("   not enough data"))
+				slowest.WriteString("\n")
+			}
+			slowest.WriteString("\n")
+
+			errors.WriteString(subtleStyle.Render(fmt.Sprintf("most error-prone %s:", ngramName(n))))
+			errors.WriteString("\n")
+			stats = ng.GetTopErrors(n, 5)
+			for _, stat := range stats {
+				level := storage.GetErrorHeatLevel(stat.ErrorRate())
+				keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(storage.GetHeatColor(level))).Bold(true)
+				errors.WriteString(fmt.Sprintf("   %s %s\n",
+					keyStyle.Render(fmt.Sprintf("%-4s", stat.NGram)),
+					subtleStyle.Render(fmt.Sprintf("%.1f%% (%d/%d)", stat.ErrorRate(), stat.Errors, stat.Count))))
+			}
+			if len(stats) == 0 {
+				errors.WriteString(subtleStyle.Render("   none yet"))
+				errors.WriteString("\n")
+			}
+			errors.WriteString("\n")
+		}
+
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, slowest.String(), "    ", errors.String()))
+	}
+
+	var help string
+	if wantToQuit {
+		help = errorStyle.Render("press esc again to go back")
+	} else {
+		help = helpStyle.Render("esc to go back • r to reset")
+	}
+	s.WriteString(help)
+
+	content := containerStyle.Render(s.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// renderFingerStats renders a line per finger or hand: share of keystrokes,
+// error rate coloured like the heatmap, and speed
+func renderFingerStats(stats []storage.FingerStats) string {
+	most := 0
+	for _, f := range stats {
+		most = max(most, f.Hits)
+	}
+
+	var s strings.Builder
+	for _, f := range stats {
+		errorRate := f.ErrorRate()
+		heatStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(storage.GetHeatColor(storage.GetErrorHeatLevel(errorRate))))
+		speed := "-"
+		if f.Timed > 0 {
+			speed = fmt.Sprintf("%.0f", f.WPM())
+		}
+		s.WriteString(fmt.Sprintf("   %s %s %s %s %s\n",
+			subtleStyle.Render(fmt.Sprintf("%-12s", f.Name)),
+			renderBar(f.Hits, most, 10),
+			statsStyle.Render(fmt.Sprintf("%5.1f%%", f.Share)),
+			heatStyle.Render(fmt.Sprintf("%5.1f%% errors", errorRate)),
+			wpmStyle.Render(fmt.Sprintf("%3s", speed))+subtleStyle.Render(" wpm")))
+	}
+	return s.String()
+}
+
+// ngramName returns the plural name of n-grams of length n
+func ngramName(n int) string {
+	if n == 2 {
+		return "bigrams"
+	}
+	return "trigrams"
+}
+
 // RenderChallenges renders the daily challenges screen
 func RenderChallenges(dc *storage.DailyChallenges, width, height int, wantToQuit bool) string {
 	var s strings.Builder
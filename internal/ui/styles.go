@@ -14,6 +14,8 @@ var (
 	colorError   = lipgloss.Color("#ca4754")
 	colorAccent  = lipgloss.Color("#5eacd3")
 	colorCorrect = lipgloss.Color("#98c379")
+	colorGhost   = lipgloss.Color("#8b7fb8")
+	colorWarning = lipgloss.Color("#e2b714")
 )
 
 // Styles
@@ -79,6 +81,10 @@ var (
 	newPBStyle = lipgloss.NewStyle().
 			Foreground(colorAccent).
 			Bold(true)
+
+	ghostStyle = lipgloss.NewStyle().
+			Foreground(colorBg).
+			Background(colorGhost)
 )
 
 // Enhanced error visualization colors
@@ -110,6 +116,9 @@ var (
 	errorDetailStyle = lipgloss.NewStyle().
 				Foreground(colorSubtle).
 				Italic(true)
+
+	noticeStyle = lipgloss.NewStyle().
+			Foreground(colorWarning)
 )
 
 // UpdateAccentColor updates the accent color and all dependent styles
@@ -0,0 +1,90 @@
+package words
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Target is a letter or letter sequence the typist struggles with. Weight
+// says how much, from 0 (no trouble) to 1 (the worst of its kind).
+type Target struct {
+	Text   string
+	Weight float64
+}
+
+// adaptiveBoost is how much more likely a word becomes for each full-weight
+// target it contains. A word with the worst key twice is drawn 1+2*4 = 9
+// times as often as a word with none.
+const adaptiveBoost = 4.0
+
+// AdaptiveSource draws words from a list, favouring words that contain the
+// targets so practice time goes to the keys and transitions that need it.
+// Without targets every word is equally likely, as with GetRandom.
+type AdaptiveSource struct {
+	Targets    []Target
+	Complexity Complexity
+
+	words      []string
+	cumulative []float64 // Running total of the word weights, for sampling
+}
+
+// NewAdaptiveSource creates a word source over list weighted toward targets
+func NewAdaptiveSource(list []string, complexity Complexity, targets []Target) *AdaptiveSource {
+	s := &AdaptiveSource{Complexity: complexity, words: list}
+	s.Retarget(targets)
+	return s
+}
+
+// Retarget replaces the targets, e.g. once a test has updated the statistics
+// they were taken from
+func (s *AdaptiveSource) Retarget(targets []Target) {
+	s.Targets = targets
+	s.cumulative = make([]float64, len(s.words))
+	total := 0.0
+	for i, word := range s.words {
+		total += s.Weight(word)
+		s.cumulative[i] = total
+	}
+}
+
+// Weight returns how likely word is to be drawn relative to a word that
+// contains no target
+func (s *AdaptiveSource) Weight(word string) float64 {
+	weight := 1.0
+	word = strings.ToLower(word)
+	for _, t := range s.Targets {
+		if t.Text != "" {
+			weight += adaptiveBoost * t.Weight * float64(strings.Count(word, t.Text))
+		}
+	}
+	return weight
+}
+
+// Next returns n words with the complexity modifiers applied, never the same
+// word twice in a row
+func (s *AdaptiveSource) Next(n int) []string {
+	if n <= 0 || len(s.words) == 0 {
+		return []string{}
+	}
+
+	words := make([]string, n)
+	for i := range words {
+		word := s.pick()
+		for i > 0 && word == words[i-1] && len(s.words) > 1 {
+			word = s.pick()
+		}
+		words[i] = word
+	}
+	return ApplyComplexity(words, s.Complexity)
+}
+
+// pick draws one word according to the weights
+func (s *AdaptiveSource) pick() string {
+	total := s.cumulative[len(s.cumulative)-1]
+	i := sort.SearchFloat64s(s.cumulative, rand.Float64()*total)
+	if i >= len(s.words) {
+		i = len(s.words) - 1
+	}
+	return s.words[i]
+}
@@ -0,0 +1,63 @@
+package words
+
+import "testing"
+
+func TestAdaptiveSourceWeight(t *testing.T) {
+	src := NewAdaptiveSource([]string{"cat"}, ComplexityNormal, []Target{
+		{Text: "z", Weight: 1},
+		{Text: "th", Weight: 0.5},
+	})
+
+	tests := []struct {
+		word     string
+		expected float64
+	}{
+		{"cat", 1},
+		{"zoo", 5},
+		{"the", 3},
+		{"zither", 7},
+		{"Jazz", 9},
+	}
+
+	for _, tt := range tests {
+		if got := src.Weight(tt.word); got != tt.expected {
+			t.Errorf("Weight(%q) = %v, expected %v", tt.word, got, tt.expected)
+		}
+	}
+}
+
+func TestAdaptiveSourceFavoursTargets(t *testing.T) {
+	list := []string{"quiz", "cat", "dog", "sun"}
+	src := NewAdaptiveSource(list, ComplexityNormal, []Target{{Text: "q", Weight: 1}})
+
+	counts := make(map[string]int)
+	for _, w := range src.Next(4000) {
+		counts[w]++
+	}
+
+	// quiz is 5 times as likely as each other word; never repeating a word
+	// brings that down to 5/12 of all draws
+	if counts["quiz"] < 1400 {
+		t.Errorf("Expected quiz to be drawn most often, got %v", counts)
+	}
+
+	// Without targets it falls back to uniform sampling
+	src.Retarget(nil)
+	counts = make(map[string]int)
+	for _, w := range src.Next(4000) {
+		counts[w]++
+	}
+	if counts["quiz"] > 1500 {
+		t.Errorf("Expected quiz to be drawn about a quarter of the time, got %v", counts)
+	}
+}
+
+func TestAdaptiveSourceNoConsecutiveDuplicates(t *testing.T) {
+	src := NewAdaptiveSource([]string{"a", "b"}, ComplexityNormal, []Target{{Text: "a", Weight: 1}})
+	words := src.Next(100)
+	for i := 1; i < len(words); i++ {
+		if words[i] == words[i-1] {
+			t.Fatalf("Word %q repeated at %d", words[i], i)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package words
 import (
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestComplexityString(t *testing.T) {
@@ -25,6 +26,22 @@ func TestComplexityString(t *testing.T) {
 	}
 }
 
+func TestParseComplexity(t *testing.T) {
+	for _, c := range []Complexity{ComplexityNormal, ComplexityPunctuation, ComplexityNumbers, ComplexityFull} {
+		parsed, err := ParseComplexity(c.String())
+		if err != nil {
+			t.Errorf("ParseComplexity(%q) returned error: %v", c.String(), err)
+		}
+		if parsed != c {
+			t.Errorf("ParseComplexity(%q) = %v, expected %v", c.String(), parsed, c)
+		}
+	}
+
+	if _, err := ParseComplexity("emoji"); err == nil {
+		t.Error("Expected error for unknown complexity")
+	}
+}
+
 func TestGetRandomWithComplexityNormal(t *testing.T) {
 	words := GetRandomWithComplexity(50, DifficultyMedium, ComplexityNormal)
 	if len(words) != 50 {
@@ -136,3 +153,12 @@ func TestAddPunctuation(t *testing.T) {
 		}
 	}
 }
+
+func TestAddPunctuationMultibyte(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		result := AddPunctuation("źdźbło")
+		if !utf8.ValidString(result) {
+			t.Fatalf("AddPunctuation split a multibyte letter: %q", result)
+		}
+	}
+}
@@ -16,7 +16,11 @@ func GetRandom(n int, difficulty Difficulty) []string {
 		return []string{}
 	}
 
-	wordList := GetList(difficulty)
+	return pickRandom(n, GetList(difficulty))
+}
+
+// pickRandom returns n words sampled from wordList without consecutive duplicates
+func pickRandom(n int, wordList []string) []string {
 	words := make([]string, n)
 	for i := 0; i < n; i++ {
 		word := wordList[rand.Intn(len(wordList))]
@@ -50,14 +54,18 @@ func GetRandomWithComplexity(n int, difficulty Difficulty, complexity Complexity
 		return []string{}
 	}
 
-	baseWords := GetRandom(n, difficulty)
+	return ApplyComplexity(GetRandom(n, difficulty), complexity)
+}
+
+// ApplyComplexity adds punctuation and numbers to words according to complexity
+func ApplyComplexity(baseWords []string, complexity Complexity) []string {
 	if complexity == ComplexityNormal {
 		return baseWords
 	}
 
-	words := make([]string, n)
+	words := make([]string, len(baseWords))
 
-	for i := 0; i < n; i++ {
+	for i := range baseWords {
 		word := baseWords[i]
 
 		switch complexity {
@@ -115,9 +123,9 @@ func AddPunctuation(word string) string {
 		return word + punctuationCombos[rand.Intn(6)+8] // 's, n't, 're, 'll, 'd, 've, 'm
 	default:
 		// Add internal punctuation
-		if len(word) > 2 {
-			mid := len(word) / 2
-			return word[:mid] + punctuationMarks[rand.Intn(len(punctuationMarks))] + word[mid:]
+		if runes := []rune(word); len(runes) > 2 {
+			mid := len(runes) / 2
+			return string(runes[:mid]) + punctuationMarks[rand.Intn(len(punctuationMarks))] + string(runes[mid:])
 		}
 		return word + punctuationMarks[rand.Intn(len(punctuationMarks))]
 	}
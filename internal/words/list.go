@@ -0,0 +1,71 @@
+package words
+
+import "fmt"
+
+// Order controls how words are drawn from a custom word list
+type Order int
+
+const (
+	OrderRandom Order = iota
+	OrderSequential
+)
+
+// String returns a string representation of the order
+func (o Order) String() string {
+	if o == OrderSequential {
+		return "sequential"
+	}
+	return "random"
+}
+
+// ParseOrder parses an order name as returned by Order.String
+func ParseOrder(s string) (Order, error) {
+	switch s {
+	case "random":
+		return OrderRandom, nil
+	case "sequential":
+		return OrderSequential, nil
+	default:
+		return OrderRandom, fmt.Errorf("unknown order %q (want random or sequential)", s)
+	}
+}
+
+// ListSource draws words from a custom word list, keeping its place so that
+// sequential lists continue where they left off when more words are needed
+type ListSource struct {
+	Name       string
+	Words      []string
+	Order      Order
+	Complexity Complexity
+	next       int
+}
+
+// NewListSource creates a word source for a custom word list
+func NewListSource(name string, list []string, order Order, complexity Complexity) *ListSource {
+	return &ListSource{
+		Name:       name,
+		Words:      list,
+		Order:      order,
+		Complexity: complexity,
+	}
+}
+
+// Next returns the next n words with the complexity modifiers applied
+func (s *ListSource) Next(n int) []string {
+	if n <= 0 || len(s.Words) == 0 {
+		return []string{}
+	}
+
+	var words []string
+	if s.Order == OrderSequential {
+		words = make([]string, n)
+		for i := range words {
+			words[i] = s.Words[s.next%len(s.Words)]
+			s.next++
+		}
+	} else {
+		words = pickRandom(n, s.Words)
+	}
+
+	return ApplyComplexity(words, s.Complexity)
+}
@@ -0,0 +1,70 @@
+package words
+
+import "testing"
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Order
+		wantErr  bool
+	}{
+		{"random", OrderRandom, false},
+		{"sequential", OrderSequential, false},
+		{"shuffled", OrderRandom, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOrder(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOrder(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseOrder(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+		if !tt.wantErr && got.String() != tt.input {
+			t.Errorf("Order(%d).String() = %q, expected %q", got, got.String(), tt.input)
+		}
+	}
+}
+
+func TestListSourceSequential(t *testing.T) {
+	src := NewListSource("fruits", []string{"apple", "banana", "cherry"}, OrderSequential, ComplexityNormal)
+
+	first := src.Next(4)
+	expected := []string{"apple", "banana", "cherry", "apple"}
+	for i, w := range expected {
+		if first[i] != w {
+			t.Errorf("Next(4)[%d] = %q, expected %q", i, first[i], w)
+		}
+	}
+
+	// A refill continues where the previous call stopped
+	if next := src.Next(1); next[0] != "banana" {
+		t.Errorf("Expected refill to continue with 'banana', got %q", next[0])
+	}
+}
+
+func TestListSourceRandom(t *testing.T) {
+	list := []string{"apple", "banana", "cherry"}
+	src := NewListSource("fruits", list, OrderRandom, ComplexityNormal)
+
+	got := src.Next(50)
+	if len(got) != 50 {
+		t.Fatalf("Expected 50 words, got %d", len(got))
+	}
+	for i, w := range got {
+		if w != "apple" && w != "banana" && w != "cherry" {
+			t.Errorf("Word %q is not from the list", w)
+		}
+		if i > 0 && w == got[i-1] {
+			t.Errorf("Consecutive duplicate %q at position %d", w, i)
+		}
+	}
+}
+
+func TestListSourceEmpty(t *testing.T) {
+	src := NewListSource("empty", nil, OrderRandom, ComplexityFull)
+	if got := src.Next(5); len(got) != 0 {
+		t.Errorf("Expected no words from an empty list, got %d", len(got))
+	}
+}
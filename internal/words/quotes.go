@@ -0,0 +1,105 @@
+package words
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"unicode/utf8"
+)
+
+//go:embed quotes.json
+var quotesJSON []byte
+
+// quotes is the bundled quote collection, parsed once at startup
+var quotes = mustLoadQuotes(quotesJSON)
+
+// Quote is a passage of real text with its attribution
+type Quote struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+}
+
+// QuoteLength groups quotes by how long they take to type
+type QuoteLength int
+
+const (
+	QuoteShort  QuoteLength = iota // up to 100 characters
+	QuoteMedium                    // 101-300 characters
+	QuoteLong                      // 301-600 characters
+	QuoteGrind                     // more than 600 characters
+)
+
+// String returns a string representation of the quote length
+func (l QuoteLength) String() string {
+	switch l {
+	case QuoteShort:
+		return "short"
+	case QuoteLong:
+		return "long"
+	case QuoteGrind:
+		return "grind"
+	default:
+		return "medium"
+	}
+}
+
+// ParseQuoteLength parses a quote length name as returned by QuoteLength.String
+func ParseQuoteLength(s string) (QuoteLength, error) {
+	switch s {
+	case "short":
+		return QuoteShort, nil
+	case "medium":
+		return QuoteMedium, nil
+	case "long":
+		return QuoteLong, nil
+	case "grind":
+		return QuoteGrind, nil
+	default:
+		return QuoteMedium, fmt.Errorf("unknown quote length %q (want short, medium, long or grind)", s)
+	}
+}
+
+// Length returns the length group the quote belongs to
+func (q Quote) Length() QuoteLength {
+	n := utf8.RuneCountInString(q.Text)
+	switch {
+	case n <= 100:
+		return QuoteShort
+	case n <= 300:
+		return QuoteMedium
+	case n <= 600:
+		return QuoteLong
+	default:
+		return QuoteGrind
+	}
+}
+
+// GetQuotes returns all bundled quotes of the given length
+func GetQuotes(length QuoteLength) []Quote {
+	var result []Quote
+	for _, q := range quotes {
+		if q.Length() == length {
+			result = append(result, q)
+		}
+	}
+	return result
+}
+
+// GetRandomQuote returns a random quote of the given length
+func GetRandomQuote(length QuoteLength) Quote {
+	pool := GetQuotes(length)
+	if len(pool) == 0 {
+		pool = quotes
+	}
+	return pool[rand.Intn(len(pool))]
+}
+
+// mustLoadQuotes parses the embedded quote collection
+func mustLoadQuotes(data []byte) []Quote {
+	var q []Quote
+	if err := json.Unmarshal(data, &q); err != nil {
+		panic(fmt.Sprintf("words: invalid bundled quotes: %v", err))
+	}
+	return q
+}
@@ -0,0 +1,80 @@
+package words
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotesLoaded(t *testing.T) {
+	if len(quotes) == 0 {
+		t.Fatal("Bundled quote collection is empty")
+	}
+
+	for i, q := range quotes {
+		if strings.TrimSpace(q.Text) == "" {
+			t.Errorf("Quote %d has empty text", i)
+		}
+		if strings.TrimSpace(q.Source) == "" {
+			t.Errorf("Quote %d (%q) has no source", i, q.Text)
+		}
+		if strings.Join(strings.Fields(q.Text), " ") != q.Text {
+			t.Errorf("Quote %d has irregular whitespace: %q", i, q.Text)
+		}
+	}
+}
+
+func TestGetQuotesEveryLength(t *testing.T) {
+	for _, l := range []QuoteLength{QuoteShort, QuoteMedium, QuoteLong, QuoteGrind} {
+		pool := GetQuotes(l)
+		if len(pool) == 0 {
+			t.Errorf("No quotes of length %s", l)
+		}
+		for _, q := range pool {
+			if q.Length() != l {
+				t.Errorf("GetQuotes(%s) returned a %s quote", l, q.Length())
+			}
+		}
+	}
+}
+
+func TestGetRandomQuote(t *testing.T) {
+	q := GetRandomQuote(QuoteShort)
+	if q.Length() != QuoteShort {
+		t.Errorf("Expected short quote, got %s (%d chars)", q.Length(), len(q.Text))
+	}
+}
+
+func TestQuoteLength(t *testing.T) {
+	tests := []struct {
+		chars    int
+		expected QuoteLength
+	}{
+		{10, QuoteShort},
+		{100, QuoteShort},
+		{101, QuoteMedium},
+		{300, QuoteMedium},
+		{301, QuoteLong},
+		{600, QuoteLong},
+		{601, QuoteGrind},
+	}
+
+	for _, tt := range tests {
+		q := Quote{Text: strings.Repeat("a", tt.chars)}
+		if q.Length() != tt.expected {
+			t.Errorf("Quote of %d chars: got %s, expected %s", tt.chars, q.Length(), tt.expected)
+		}
+	}
+}
+
+func TestParseQuoteLength(t *testing.T) {
+	for _, l := range []QuoteLength{QuoteShort, QuoteMedium, QuoteLong, QuoteGrind} {
+		parsed, err := ParseQuoteLength(l.String())
+		if err != nil || parsed != l {
+			t.Errorf("ParseQuoteLength(%q) = %v, %v", l.String(), parsed, err)
+		}
+	}
+
+	if _, err := ParseQuoteLength("epic"); err == nil {
+		t.Error("Expected error for unknown quote length")
+	}
+}
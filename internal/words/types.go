@@ -1,5 +1,7 @@
 package words
 
+import "fmt"
+
 // Difficulty represents word difficulty level
 type Difficulty int
 
@@ -23,6 +25,20 @@ func (d Difficulty) String() string {
 	}
 }
 
+// ParseDifficulty parses a difficulty name as returned by Difficulty.String
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch s {
+	case "easy":
+		return DifficultyEasy, nil
+	case "medium":
+		return DifficultyMedium, nil
+	case "hard":
+		return DifficultyHard, nil
+	default:
+		return DifficultyMedium, fmt.Errorf("unknown difficulty %q (want easy, medium or hard)", s)
+	}
+}
+
 // Complexity represents additional complexity options
 type Complexity int
 
@@ -46,3 +62,19 @@ func (c Complexity) String() string {
 		return "normal"
 	}
 }
+
+// ParseComplexity parses a complexity name as returned by Complexity.String
+func ParseComplexity(s string) (Complexity, error) {
+	switch s {
+	case "normal":
+		return ComplexityNormal, nil
+	case "punctuation":
+		return ComplexityPunctuation, nil
+	case "numbers":
+		return ComplexityNumbers, nil
+	case "full":
+		return ComplexityFull, nil
+	default:
+		return ComplexityNormal, fmt.Errorf("unknown complexity %q (want normal, punctuation, numbers or full)", s)
+	}
+}
@@ -229,3 +229,19 @@ func TestDifficultyString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDifficulty(t *testing.T) {
+	for _, d := range []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard} {
+		parsed, err := ParseDifficulty(d.String())
+		if err != nil {
+			t.Errorf("ParseDifficulty(%q) returned error: %v", d.String(), err)
+		}
+		if parsed != d {
+			t.Errorf("ParseDifficulty(%q) = %v, expected %v", d.String(), parsed, d)
+		}
+	}
+
+	if _, err := ParseDifficulty("insane"); err == nil {
+		t.Error("Expected error for unknown difficulty")
+	}
+}